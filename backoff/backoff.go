@@ -0,0 +1,86 @@
+// Package backoff retries operations with delays measured on a gotime.Clock
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Policy decides how long to wait between attempts
+type Policy interface {
+	// Delay returns how long to wait before retry number attempt, counting from 1, given the delay before the
+	// previous retry, which is 0 for the first
+	Delay(attempt int, prev time.Duration) time.Duration
+}
+
+// Constant waits the same delay before every retry
+type Constant time.Duration
+
+func (c Constant) Delay(int, time.Duration) time.Duration {
+	return time.Duration(c)
+}
+
+// Exponential waits Initial before the first retry, multiplying the delay by Multiplier for every retry after that,
+// up to Max
+type Exponential struct {
+	Initial time.Duration
+	// Multiplier defaults to 2 if not set
+	Multiplier float64
+	// Max is no limit if not set
+	Max time.Duration
+}
+
+func (e Exponential) Delay(attempt int, _ time.Duration) time.Duration {
+	m := e.Multiplier
+	if m == 0 {
+		m = 2
+	}
+
+	d := float64(e.Initial) * math.Pow(m, float64(attempt-1))
+	if e.Max > 0 && d > float64(e.Max) {
+		return e.Max
+	}
+	// Saturate rather than overflow when there is no limit
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// DecorrelatedJitter waits a random delay between Base and three times the previous delay, up to Cap, as described
+// in https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/. It is not safe for concurrent use
+// when Rand is set, as rand.Rand isn't.
+type DecorrelatedJitter struct {
+	Base time.Duration
+	// Cap is no limit if not set
+	Cap time.Duration
+	// Rand is the source of jitter, seeded for reproducible delays. The global source is used if not set.
+	Rand *rand.Rand
+}
+
+func (j DecorrelatedJitter) Delay(_ int, prev time.Duration) time.Duration {
+	if prev < j.Base {
+		prev = j.Base
+	}
+	// So that three times it still fits in a time.Duration, without a cap to stop it growing
+	if prev > math.MaxInt64/3 {
+		prev = math.MaxInt64 / 3
+	}
+
+	d := j.Base
+	if spread := 3*prev - j.Base; spread > 0 {
+		d += time.Duration(j.int63n(int64(spread)))
+	}
+	if j.Cap > 0 && d > j.Cap {
+		return j.Cap
+	}
+	return d
+}
+
+func (j DecorrelatedJitter) int63n(n int64) int64 {
+	if j.Rand == nil {
+		return rand.Int63n(n)
+	}
+	return j.Rand.Int63n(n)
+}
@@ -0,0 +1,100 @@
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func delays(p Policy, n int) []time.Duration {
+	var ds []time.Duration
+	var prev time.Duration
+	for attempt := 1; attempt <= n; attempt++ {
+		prev = p.Delay(attempt, prev)
+		ds = append(ds, prev)
+	}
+	return ds
+}
+
+func TestPolicies(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Policy
+		exp  []time.Duration
+	}{
+		{
+			name: "constant",
+			p:    Constant(time.Second),
+			exp:  []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name: "exponential",
+			p:    Exponential{Initial: 100 * time.Millisecond},
+			exp:  []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond},
+		},
+		{
+			name: "exponential capped",
+			p:    Exponential{Initial: time.Second, Multiplier: 3, Max: 10 * time.Second},
+			exp:  []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 10 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := delays(tt.p, len(tt.exp)); !reflect.DeepEqual(got, tt.exp) {
+				t.Errorf("got %s, want %s", got, tt.exp)
+			}
+		})
+	}
+}
+
+func TestExponential_Saturates(t *testing.T) {
+	p := Exponential{Initial: time.Hour}
+	if got := p.Delay(1000, 0); got <= 0 {
+		t.Errorf("got %s, want a positive delay", got)
+	}
+}
+
+func TestDecorrelatedJitter(t *testing.T) {
+	newPolicy := func() Policy {
+		return DecorrelatedJitter{Base: 100 * time.Millisecond, Cap: 5 * time.Second, Rand: rand.New(rand.NewSource(42))}
+	}
+
+	got := delays(newPolicy(), 20)
+	if again := delays(newPolicy(), 20); !reflect.DeepEqual(got, again) {
+		t.Errorf("got %s then %s, want the same seeded delays", got, again)
+	}
+
+	prev := time.Duration(0)
+	for _, d := range got {
+		max := 3 * prev
+		if prev < 100*time.Millisecond {
+			max = 300 * time.Millisecond
+		}
+		if max > 5*time.Second {
+			max = 5 * time.Second
+		}
+		if d < 100*time.Millisecond || d > max {
+			t.Errorf("got %s after %s, want between %s and %s", d, prev, 100*time.Millisecond, max)
+		}
+		prev = d
+	}
+}
+
+func TestDecorrelatedJitter_NoCap(t *testing.T) {
+	p := DecorrelatedJitter{Base: time.Second, Rand: rand.New(rand.NewSource(42))}
+
+	// Without a cap delays grow until three times the previous one would overflow, so never fall back to exactly Base
+	prev := time.Duration(0)
+	for i, d := range delays(p, 1000) {
+		max := 3 * time.Duration(math.MaxInt64/3)
+		if prev < math.MaxInt64/3 {
+			max = 3 * prev
+		}
+		if d <= time.Second || (prev > 0 && d > max) {
+			t.Fatalf("%d: got %s after %s, want above %s and at most %s", i, d, prev, time.Second, max)
+		}
+		prev = d
+	}
+}
@@ -0,0 +1,113 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+var (
+	// ErrMaxAttempts is returned, wrapping the last error, once the operation has been tried as often as allowed
+	ErrMaxAttempts = errors.New("maximum attempts reached")
+	// ErrMaxElapsedTime is returned, wrapping the last error, when waiting for another attempt would take too long
+	ErrMaxElapsedTime = errors.New("maximum elapsed time reached")
+)
+
+// Option configures Retry
+type Option func(*options)
+
+type options struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+}
+
+// WithMaxAttempts gives up after n attempts in total, counting the first
+func WithMaxAttempts(n int) Option {
+	return func(o *options) {
+		o.maxAttempts = n
+	}
+}
+
+// WithMaxElapsedTime gives up rather than wait for an attempt that would start more than d after the first
+func WithMaxElapsedTime(d time.Duration) Option {
+	return func(o *options) {
+		o.maxElapsed = d
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps err to stop Retry from trying again, which returns err itself
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// Retry calls op until it succeeds, waiting between attempts on c for as long as p says. It stops early when op returns
+// an error wrapped by Permanent, when ctx is done, or when either limit in opts is reached, returning the reason
+// wrapped together with the last error from op.
+func Retry(ctx context.Context, c gotime.Clock, p Policy, op func(ctx context.Context) error, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	start := c.Now()
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+
+		if o.maxAttempts > 0 && attempt >= o.maxAttempts {
+			return fmt.Errorf("%w after %d attempts: %w", ErrMaxAttempts, attempt, err)
+		}
+
+		delay = p.Delay(attempt, delay)
+		if o.maxElapsed > 0 && c.Since(start)+delay > o.maxElapsed {
+			return fmt.Errorf("%w after %d attempts: %w", ErrMaxElapsedTime, attempt, err)
+		}
+
+		if waitErr := wait(ctx, c, delay); waitErr != nil {
+			return fmt.Errorf("%w after %d attempts: %w", waitErr, attempt, err)
+		}
+	}
+}
+
+// wait waits for d on c, returning early with the context's error if ctx is done first
+func wait(ctx context.Context, c gotime.Clock, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	t := c.Timer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,188 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+var errFailed = errors.New("failed")
+
+// attempts records the time of every attempt, failing all but the last of succeedOn
+type attempts struct {
+	c         gotime.Clock
+	succeedOn int
+
+	mu    sync.Mutex
+	times []time.Duration
+}
+
+func (a *attempts) op(context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.times = append(a.times, a.c.Since(time.Unix(0, 0)))
+	if len(a.times) == a.succeedOn {
+		return nil
+	}
+	return errFailed
+}
+
+func (a *attempts) get() []time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]time.Duration(nil), a.times...)
+}
+
+// retry runs Retry in the background, stepping the clock to each wait until it returns
+func retry(t *testing.T, c gotime.SettableClock, p Policy, a *attempts, opts ...Option) error {
+	t.Helper()
+	return retryContext(t, context.Background(), c, p, a, opts...)
+}
+
+func retryContext(t *testing.T, ctx context.Context, c gotime.SettableClock, p Policy, a *attempts, opts ...Option) error {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() { done <- Retry(ctx, c, p, a.op, opts...) }()
+
+	for {
+		waitCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		waiting := make(chan error, 1)
+		go func() { waiting <- c.WaitForTimer(waitCtx) }()
+
+		select {
+		case err := <-done:
+			cancel()
+			return err
+		case err := <-waiting:
+			cancel()
+			if err != nil {
+				t.Fatalf("waiting for a retry: %s", err)
+			}
+			c.AdvanceToNext()
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	c := gotime.NewSettableClock()
+	a := &attempts{c: c, succeedOn: 4}
+
+	if err := retry(t, c, Exponential{Initial: time.Second}, a); err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	exp := []time.Duration{0, time.Second, 3 * time.Second, 7 * time.Second}
+	if got := a.get(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %s, want %s", got, exp)
+	}
+}
+
+func TestRetry_Jitter(t *testing.T) {
+	run := func() []time.Duration {
+		c := gotime.NewSettableClock()
+		a := &attempts{c: c, succeedOn: 6}
+
+		p := DecorrelatedJitter{Base: time.Second, Cap: time.Minute, Rand: rand.New(rand.NewSource(7))}
+		if err := retry(t, c, p, a); err != nil {
+			t.Fatalf("got %s, want nil", err)
+		}
+		return a.get()
+	}
+
+	got := run()
+	if len(got) != 6 {
+		t.Fatalf("got %d attempts, want 6", len(got))
+	}
+	if again := run(); !reflect.DeepEqual(got, again) {
+		t.Errorf("got %s then %s, want the same seeded attempts", got, again)
+	}
+}
+
+func TestRetry_MaxAttempts(t *testing.T) {
+	c := gotime.NewSettableClock()
+	a := &attempts{c: c}
+
+	err := retry(t, c, Constant(time.Second), a, WithMaxAttempts(3))
+	if !errors.Is(err, ErrMaxAttempts) || !errors.Is(err, errFailed) {
+		t.Errorf("got %v, want %s wrapping %s", err, ErrMaxAttempts, errFailed)
+	}
+
+	exp := []time.Duration{0, time.Second, 2 * time.Second}
+	if got := a.get(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %s, want %s", got, exp)
+	}
+}
+
+func TestRetry_MaxElapsedTime(t *testing.T) {
+	c := gotime.NewSettableClock()
+	a := &attempts{c: c}
+
+	err := retry(t, c, Exponential{Initial: time.Second}, a, WithMaxElapsedTime(10*time.Second))
+	if !errors.Is(err, ErrMaxElapsedTime) || !errors.Is(err, errFailed) {
+		t.Errorf("got %v, want %s wrapping %s", err, ErrMaxElapsedTime, errFailed)
+	}
+
+	// Waiting 8s more after the attempt at 7s would pass 10s
+	exp := []time.Duration{0, time.Second, 3 * time.Second, 7 * time.Second}
+	if got := a.get(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %s, want %s", got, exp)
+	}
+}
+
+func TestRetry_Permanent(t *testing.T) {
+	c := gotime.NewSettableClock()
+
+	var calls int
+	err := Retry(context.Background(), c, Constant(time.Second), func(context.Context) error {
+		calls++
+		return Permanent(errFailed)
+	})
+	if err != errFailed {
+		t.Errorf("got %v, want %s", err, errFailed)
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls, want 1", calls)
+	}
+}
+
+func TestRetry_Cancel(t *testing.T) {
+	c := gotime.NewSettableClock()
+	a := &attempts{c: c}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Retry(ctx, c, Constant(time.Second), a.op) }()
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer waitCancel()
+	if err := c.WaitForTimer(waitCtx); err != nil {
+		t.Fatalf("waiting for a retry: %s", err)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, errFailed) {
+			t.Errorf("got %v, want %s wrapping %s", err, context.Canceled, errFailed)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("retry never returned")
+	}
+
+	if got := len(a.get()); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+	// The wait's timer is stopped again
+	if got := len(c.Timers()); got != 0 {
+		t.Errorf("got %d timers, want 0", got)
+	}
+}
@@ -0,0 +1,212 @@
+// Package calendar does arithmetic in business time, counting only the working hours of working days
+package calendar
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrInvalidWindow is what New panics with when working hours are empty, overlap or fall outside a day
+	ErrInvalidWindow = errors.New("invalid working hours")
+	// ErrNoBusinessHours is what New panics with when every day of the week is a weekend
+	ErrNoBusinessHours = errors.New("calendar has no business hours")
+)
+
+// Window is a span of working hours within a day, given as wall clock offsets from midnight
+type Window struct {
+	Start, End time.Duration
+}
+
+// Option configures a Calendar created by New
+type Option func(*Calendar)
+
+// WithWeekend sets the days of the week that are never worked, instead of Saturday and Sunday
+func WithWeekend(days ...time.Weekday) Option {
+	return func(c *Calendar) {
+		c.weekend = [7]bool{}
+		for _, d := range days {
+			c.weekend[d] = true
+		}
+	}
+}
+
+// WithHours sets the working hours of each working day, instead of 09:00 to 17:00
+func WithHours(windows ...Window) Option {
+	return func(c *Calendar) {
+		c.hours = append([]Window(nil), windows...)
+	}
+}
+
+// WithHolidays adds days that are never worked. Only the date of each time, in its own location, is used.
+func WithHolidays(dates ...time.Time) Option {
+	return func(c *Calendar) {
+		for _, d := range dates {
+			c.holidays[dateOf(d)] = struct{}{}
+		}
+	}
+}
+
+// Calendar describes when business happens in a location. It is safe for concurrent use.
+type Calendar struct {
+	loc      *time.Location
+	weekend  [7]bool
+	hours    []Window
+	holidays map[date]struct{}
+}
+
+// New returns a Calendar for loc, working 09:00 to 17:00 Monday to Friday unless configured otherwise by opts.
+// It panics with ErrInvalidWindow or ErrNoBusinessHours if the options leave no sensible working hours.
+func New(loc *time.Location, opts ...Option) *Calendar {
+	c := &Calendar{
+		loc:      loc,
+		hours:    []Window{{Start: 9 * time.Hour, End: 17 * time.Hour}},
+		holidays: make(map[date]struct{}),
+	}
+	c.weekend[time.Saturday] = true
+	c.weekend[time.Sunday] = true
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if len(c.hours) == 0 {
+		panic(ErrInvalidWindow)
+	}
+	sort.Slice(c.hours, func(i, j int) bool { return c.hours[i].Start < c.hours[j].Start })
+	for i, w := range c.hours {
+		if w.Start < 0 || w.End > 24*time.Hour || w.Start >= w.End || (i > 0 && w.Start < c.hours[i-1].End) {
+			panic(ErrInvalidWindow)
+		}
+	}
+
+	working := false
+	for _, weekend := range c.weekend {
+		working = working || !weekend
+	}
+	if !working {
+		panic(ErrNoBusinessHours)
+	}
+
+	return c
+}
+
+// date is a day in the calendar, independent of any location
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) date {
+	y, m, d := t.Date()
+	return date{year: y, month: m, day: d}
+}
+
+// next returns the following day
+func (d date) next() date {
+	return dateOf(time.Date(d.year, d.month, d.day+1, 0, 0, 0, 0, time.UTC))
+}
+
+// at returns the instant of the wall clock offset from midnight on d in loc
+func (d date) at(offset time.Duration, loc *time.Location) time.Time {
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, int(offset), loc)
+}
+
+// IsBusinessDay reports whether any business happens on the day of t in the calendar's location
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	return c.isBusinessDay(dateOf(t.In(c.loc)))
+}
+
+func (c *Calendar) isBusinessDay(d date) bool {
+	if _, ok := c.holidays[d]; ok {
+		return false
+	}
+	return !c.weekend[time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC).Weekday()]
+}
+
+// IsBusinessTime reports whether t is within working hours
+func (c *Calendar) IsBusinessTime(t time.Time) bool {
+	return c.NextBusinessInstant(t).Equal(t)
+}
+
+// periods calls f with each span of working hours that ends after t, in order, until f returns false
+func (c *Calendar) periods(t time.Time, f func(start, end time.Time) bool) {
+	for d := dateOf(t.In(c.loc)); ; d = d.next() {
+		if !c.isBusinessDay(d) {
+			continue
+		}
+
+		for _, w := range c.hours {
+			start, end := d.at(w.Start, c.loc), d.at(w.End, c.loc)
+			if !end.After(t) {
+				continue
+			}
+			if !f(start, end) {
+				return
+			}
+		}
+	}
+}
+
+// NextBusinessInstant returns t if it is within working hours, otherwise the start of the next working hours
+func (c *Calendar) NextBusinessInstant(t time.Time) time.Time {
+	var next time.Time
+	c.periods(t, func(start, end time.Time) bool {
+		next = later(start, t)
+		return false
+	})
+	return next
+}
+
+// AddBusinessDuration returns the instant once d of business time has passed from t. When d runs out exactly at the
+// end of working hours, that is the instant returned. A non-positive d returns t.
+func (c *Calendar) AddBusinessDuration(t time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return t
+	}
+
+	var until time.Time
+	c.periods(t, func(start, end time.Time) bool {
+		from := later(start, t)
+		if left := end.Sub(from); d > left {
+			d -= left
+			return true
+		}
+		until = from.Add(d)
+		return false
+	})
+	return until
+}
+
+// BusinessDurationBetween returns how much business time passes from a to b, which is negative if b is before a
+func (c *Calendar) BusinessDurationBetween(a, b time.Time) time.Duration {
+	if b.Before(a) {
+		return -c.BusinessDurationBetween(b, a)
+	}
+
+	var total time.Duration
+	c.periods(a, func(start, end time.Time) bool {
+		if !start.Before(b) {
+			return false
+		}
+		total += earlier(end, b).Sub(later(start, a))
+		return true
+	})
+	return total
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
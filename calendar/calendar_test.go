@@ -0,0 +1,163 @@
+package calendar
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// Working 09:00 to 12:00 and 13:00 to 17:00, with Monday 15 March 2021 as a holiday
+func newTestCalendar() *Calendar {
+	return New(time.UTC,
+		WithHours(
+			Window{Start: 13 * time.Hour, End: 17 * time.Hour},
+			Window{Start: 9 * time.Hour, End: 12 * time.Hour},
+		),
+		WithHolidays(time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)),
+	)
+}
+
+// at returns the time on the day of March 2021, where the 12th is a Friday
+func at(day, hour, min int) time.Time {
+	return time.Date(2021, time.March, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestNextBusinessInstant(t *testing.T) {
+	c := newTestCalendar()
+
+	tests := []struct {
+		name string
+		t    time.Time
+		exp  time.Time
+	}{
+		{name: "working", t: at(12, 10, 0), exp: at(12, 10, 0)},
+		{name: "start of day", t: at(12, 9, 0), exp: at(12, 9, 0)},
+		{name: "before work", t: at(12, 8, 0), exp: at(12, 9, 0)},
+		{name: "lunch", t: at(12, 12, 30), exp: at(12, 13, 0)},
+		{name: "end of day skips weekend and holiday", t: at(12, 17, 0), exp: at(16, 9, 0)},
+		{name: "weekend", t: at(13, 11, 0), exp: at(16, 9, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.NextBusinessInstant(tt.t); !got.Equal(tt.exp) {
+				t.Errorf("got %s, want %s", got, tt.exp)
+			}
+			if got, exp := c.IsBusinessTime(tt.t), tt.t.Equal(tt.exp); got != exp {
+				t.Errorf("got %t, want %t", got, exp)
+			}
+		})
+	}
+}
+
+func TestAddBusinessDuration(t *testing.T) {
+	c := newTestCalendar()
+
+	tests := []struct {
+		name string
+		t    time.Time
+		d    time.Duration
+		exp  time.Time
+	}{
+		{name: "same window", t: at(12, 10, 0), d: time.Hour, exp: at(12, 11, 0)},
+		{name: "over lunch", t: at(12, 11, 30), d: time.Hour, exp: at(12, 13, 30)},
+		{name: "ends at close", t: at(12, 16, 0), d: time.Hour, exp: at(12, 17, 0)},
+		{name: "over weekend and holiday", t: at(12, 16, 0), d: 2 * time.Hour, exp: at(16, 10, 0)},
+		{name: "from weekend", t: at(13, 0, 0), d: 8 * time.Hour, exp: at(17, 10, 0)},
+		{name: "from lunch", t: at(12, 12, 15), d: 30 * time.Minute, exp: at(12, 13, 30)},
+		{name: "zero", t: at(13, 0, 0), d: 0, exp: at(13, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.AddBusinessDuration(tt.t, tt.d)
+			if !got.Equal(tt.exp) {
+				t.Errorf("got %s, want %s", got, tt.exp)
+			}
+
+			if tt.d > 0 {
+				if between := c.BusinessDurationBetween(tt.t, got); between != tt.d {
+					t.Errorf("got %s between, want %s", between, tt.d)
+				}
+			}
+		})
+	}
+}
+
+func TestBusinessDurationBetween(t *testing.T) {
+	c := newTestCalendar()
+
+	tests := []struct {
+		name string
+		a, b time.Time
+		exp  time.Duration
+	}{
+		{name: "same window", a: at(12, 10, 0), b: at(12, 11, 30), exp: 90 * time.Minute},
+		{name: "over lunch", a: at(12, 11, 0), b: at(12, 14, 0), exp: 2 * time.Hour},
+		{name: "over weekend and holiday", a: at(12, 16, 0), b: at(16, 10, 0), exp: 2 * time.Hour},
+		{name: "backwards", a: at(16, 10, 0), b: at(12, 16, 0), exp: -2 * time.Hour},
+		{name: "weekend", a: at(13, 0, 0), b: at(14, 23, 0), exp: 0},
+		{name: "whole week", a: at(15, 0, 0), b: at(22, 0, 0), exp: 4 * 7 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.BusinessDurationBetween(tt.a, tt.b); got != tt.exp {
+				t.Errorf("got %s, want %s", got, tt.exp)
+			}
+		})
+	}
+}
+
+func TestCalendar_DST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+	c := New(berlin)
+
+	// Clocks go forward over the weekend of 27 March 2021, but working hours stay 09:00 to 17:00
+	from := time.Date(2021, time.March, 26, 16, 0, 0, 0, berlin)
+	exp := time.Date(2021, time.March, 29, 10, 0, 0, 0, berlin)
+
+	if got := c.AddBusinessDuration(from, 2*time.Hour); !got.Equal(exp) {
+		t.Errorf("got %s, want %s", got, exp)
+	}
+	if got := c.BusinessDurationBetween(from, exp); got != 2*time.Hour {
+		t.Errorf("got %s, want %s", got, 2*time.Hour)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		exp  error
+	}{
+		{name: "no hours", opts: []Option{WithHours()}, exp: ErrInvalidWindow},
+		{name: "backwards", opts: []Option{WithHours(Window{Start: 17 * time.Hour, End: 9 * time.Hour})}, exp: ErrInvalidWindow},
+		{name: "past midnight", opts: []Option{WithHours(Window{Start: 22 * time.Hour, End: 26 * time.Hour})}, exp: ErrInvalidWindow},
+		{
+			name: "overlapping",
+			opts: []Option{WithHours(
+				Window{Start: 9 * time.Hour, End: 13 * time.Hour},
+				Window{Start: 12 * time.Hour, End: 17 * time.Hour},
+			)},
+			exp: ErrInvalidWindow,
+		},
+		{
+			name: "every day a weekend",
+			opts: []Option{WithWeekend(time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday)},
+			exp:  ErrNoBusinessHours,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, tt.exp) {
+					t.Errorf("got %v, want %s", err, tt.exp)
+				}
+			}()
+			New(time.UTC, tt.opts...)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package calendar
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrInvalidHolidays is returned when a holiday list can't be parsed
+var ErrInvalidHolidays = errors.New("invalid holiday list")
+
+// ParseICal returns the days covered by every VEVENT in an iCalendar (RFC 5545) file, for use with WithHolidays.
+// Events span from their DTSTART date up to, but not including, their DTEND date. Recurrence rules are not expanded.
+func ParseICal(r io.Reader) ([]time.Time, error) {
+	var (
+		dates      []time.Time
+		inEvent    bool
+		start, end time.Time
+	)
+
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// Drop parameters such as ;VALUE=DATE or ;TZID=Europe/Berlin
+		name, _, _ = strings.Cut(name, ";")
+
+		switch strings.ToUpper(name) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent = true
+				start, end = time.Time{}, time.Time{}
+			}
+
+		case "DTSTART", "DTEND":
+			if !inEvent {
+				continue
+			}
+			d, err := parseICalDate(value)
+			if err != nil {
+				return nil, err
+			}
+			if strings.EqualFold(name, "DTSTART") {
+				start = d
+			} else {
+				end = d
+			}
+
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+
+			if start.IsZero() {
+				return nil, fmt.Errorf("%w: VEVENT without DTSTART", ErrInvalidHolidays)
+			}
+			dates = append(dates, start)
+			for d := start.AddDate(0, 0, 1); d.Before(end); d = d.AddDate(0, 0, 1) {
+				dates = append(dates, d)
+			}
+		}
+	}
+
+	return dates, nil
+}
+
+// unfold reads the content lines of an iCalendar file, joining lines that were folded onto the next
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, s.Err()
+}
+
+// parseICalDate parses the date out of a DATE or DATE-TIME value, such as 20211225 or 20211225T000000Z
+func parseICalDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("%w: invalid date %q", ErrInvalidHolidays, value)
+	}
+
+	d, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid date %q", ErrInvalidHolidays, value)
+	}
+	return d, nil
+}
+
+// ParseJSON returns the days in a JSON array of dates, for use with WithHolidays. Each element is either a date
+// string such as "2021-12-25", or an object with such a string in its "date" field, like {"date": "2021-12-25",
+// "name": "Christmas Day"}.
+func ParseJSON(r io.Reader) ([]time.Time, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidHolidays, err)
+	}
+
+	dates := make([]time.Time, 0, len(raw))
+	for _, r := range raw {
+		var s string
+		if err := json.Unmarshal(r, &s); err != nil {
+			var obj struct {
+				Date string `json:"date"`
+			}
+			if err := json.Unmarshal(r, &obj); err != nil {
+				return nil, fmt.Errorf("%w: %s is neither a date nor an object with one", ErrInvalidHolidays, r)
+			}
+			s = obj.Date
+		}
+
+		d, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid date %q", ErrInvalidHolidays, s)
+		}
+		dates = append(dates, d)
+	}
+
+	return dates, nil
+}
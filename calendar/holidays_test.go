@@ -0,0 +1,87 @@
+package calendar
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseICal(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20211225",
+		"DTEND;VALUE=DATE:20211227",
+		"SUMMARY:Christmas Day and",
+		"  Boxing Day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:2022",
+		" 0101",
+		"SUMMARY:New Year's Day",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=Europe/Berlin:20220415T000000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	got, err := ParseICal(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	exp := []time.Time{
+		day(2021, time.December, 25),
+		day(2021, time.December, 26),
+		day(2022, time.January, 1),
+		day(2022, time.April, 15),
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %s, want %s", got, exp)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	got, err := ParseJSON(strings.NewReader(`["2021-12-25", {"date": "2022-01-01", "name": "New Year's Day"}]`))
+	if err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	exp := []time.Time{day(2021, time.December, 25), day(2022, time.January, 1)}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("got %s, want %s", got, exp)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string) ([]time.Time, error)
+		in    string
+	}{
+		{name: "ical bad date", parse: parseICalString, in: "BEGIN:VEVENT\nDTSTART:2021\nEND:VEVENT"},
+		{name: "ical no start", parse: parseICalString, in: "BEGIN:VEVENT\nSUMMARY:Holiday\nEND:VEVENT"},
+		{name: "json not array", parse: parseJSONString, in: `{"date": "2021-12-25"}`},
+		{name: "json bad date", parse: parseJSONString, in: `["25/12/2021"]`},
+		{name: "json bad element", parse: parseJSONString, in: `[20211225]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.parse(tt.in); !errors.Is(err, ErrInvalidHolidays) {
+				t.Errorf("got %v, want %s", err, ErrInvalidHolidays)
+			}
+		})
+	}
+}
+
+func parseICalString(s string) ([]time.Time, error) { return ParseICal(strings.NewReader(s)) }
+
+func parseJSONString(s string) ([]time.Time, error) { return ParseJSON(strings.NewReader(s)) }
@@ -0,0 +1,55 @@
+package calendar
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+// BusinessTimer is a gotime.Timer that fires once an amount of business time has passed on a Clock
+type BusinessTimer struct {
+	c     gotime.Clock
+	cal   *Calendar
+	timer gotime.Timer
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+// NewBusinessTimer returns a timer that fires once d of business time has passed on c according to cal
+func NewBusinessTimer(c gotime.Clock, cal *Calendar, d time.Duration) *BusinessTimer {
+	t := &BusinessTimer{
+		c:        c,
+		cal:      cal,
+		deadline: cal.AddBusinessDuration(c.Now(), d),
+	}
+	t.timer = c.Timer(c.Until(t.deadline))
+
+	return t
+}
+
+func (t *BusinessTimer) C() <-chan time.Time {
+	return t.timer.C()
+}
+
+// Deadline returns the instant the timer fires at
+func (t *BusinessTimer) Deadline() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.deadline
+}
+
+// Reset restarts the timer to fire once d of business time has passed from now, reporting whether it was active
+func (t *BusinessTimer) Reset(d time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.deadline = t.cal.AddBusinessDuration(t.c.Now(), d)
+	return t.timer.Reset(t.c.Until(t.deadline))
+}
+
+func (t *BusinessTimer) Stop() bool {
+	return t.timer.Stop()
+}
@@ -0,0 +1,78 @@
+package calendar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+func waitForTimers(t *testing.T, c gotime.SettableClock, n int) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := c.BlockUntil(ctx, n); err != nil {
+		t.Fatalf("waiting for %d timers: %s", n, err)
+	}
+}
+
+func TestBusinessTimer(t *testing.T) {
+	c := gotime.NewSettableClock()
+	c.SetNow(at(12, 16, 0))
+
+	timer := NewBusinessTimer(c, newTestCalendar(), 2*time.Hour)
+	if exp := at(16, 10, 0); !timer.Deadline().Equal(exp) {
+		t.Fatalf("got %s, want %s", timer.Deadline(), exp)
+	}
+	waitForTimers(t, c, 1)
+
+	// An hour of business time passes by the end of Friday, then none at all over the weekend and holiday
+	c.SetNow(at(16, 9, 59))
+	select {
+	case got := <-timer.C():
+		t.Fatalf("fired early at %s", got)
+	default:
+	}
+
+	c.Add(time.Minute)
+	select {
+	case got := <-timer.C():
+		if exp := at(16, 10, 0); !got.Equal(exp) {
+			t.Errorf("got %s, want %s", got, exp)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timer never fired")
+	}
+
+	if timer.Reset(time.Hour) {
+		t.Error("got active after firing, want inactive")
+	}
+	if exp := at(16, 11, 0); !timer.Deadline().Equal(exp) {
+		t.Errorf("got %s, want %s", timer.Deadline(), exp)
+	}
+	if !timer.Stop() {
+		t.Error("got inactive after reset, want active")
+	}
+}
+
+func TestBusinessTimer_Warped(t *testing.T) {
+	base := gotime.NewSettableClock()
+	c := gotime.NewTimeWarpableClock(gotime.WithBaseClock(base), gotime.WithStartTime(at(12, 16, 0)), gotime.WithWarpSpeed(60))
+
+	timer := NewBusinessTimer(c, newTestCalendar(), 2*time.Hour)
+	waitForTimers(t, base, 1)
+
+	// The 90 hours until Tuesday morning pass in 90 minutes of the base clock
+	base.Add(90 * time.Minute)
+	select {
+	case got := <-timer.C():
+		if exp := at(16, 10, 0); got.Before(exp) {
+			t.Errorf("got %s, want %s", got, exp)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timer never fired")
+	}
+}
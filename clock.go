@@ -1,19 +1,28 @@
 package gotime
 
 import (
+	"context"
 	"time"
-
-	"github.com/mgb/gotime/internal/queue"
 )
 
 // Clock is a interface for common time functions for faking or simulatable purposes
-// TODO: Add AfterFunc, Tick, Ticker
 type Clock interface {
 	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
 	Now() time.Time
 	Since(t time.Time) time.Duration
 	Sleep(d time.Duration)
+	Tick(d time.Duration) <-chan time.Time
+	Ticker(d time.Duration) Ticker
 	Timer(d time.Duration) Timer
+	Until(t time.Time) time.Duration
+}
+
+// Ticker is an interface for time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
 }
 
 // Timer is an interface for time.Timer
@@ -28,7 +37,37 @@ type SettableClock interface {
 	// Add will add the duration to the current time, returning the old time.
 	Add(d time.Duration) time.Time
 	// SetNow sets the clock to the specified time, returning the old time. Timers will not be adjusted and will immediately trigger if time skips ahead of them.
+	// Moving backwards never fires a timer twice: timers that already fired stay fired, and pending timers keep their deadline, firing once time reaches it again.
 	SetNow(t time.Time) time.Time
+	// AddStrict is Add for monotonic time, returning ErrTimeInPast without changing the clock if d is negative.
+	AddStrict(d time.Duration) (time.Time, error)
+	// SetNowStrict is SetNow for monotonic time, returning ErrTimeInPast without changing the clock if t is before the current time.
+	SetNowStrict(t time.Time) (time.Time, error)
+	// AdvanceToNext moves the clock forward to the earliest pending timer and fires every timer due at that time,
+	// returning the new time. It returns false, leaving the clock untouched, if no timers are pending.
+	// Timers due at the same time always fire in the order they were armed, here and everywhere else. Elsewhere, each
+	// AfterFunc and Ticker callback gets a goroutine of its own, started in that order, as with time.AfterFunc.
+	// AfterFunc and Ticker callbacks run on the calling goroutine and have returned by the time AdvanceToNext does, so
+	// they must not block on the clock, such as by calling Sleep, as nothing would move it on. Arming and stopping timers
+	// from them is fine.
+	AdvanceToNext() (time.Time, bool)
+	// RunUntil fires pending timers up to and including t one deadline at a time, in order, setting the clock to each
+	// timer's own deadline as it fires so timers armed from callbacks are honoured. The clock is then left at t.
+	// Callbacks run on the calling goroutine, the same as for AdvanceToNext.
+	RunUntil(t time.Time)
+	// BlockUntil waits until exactly n timers, tickers or sleepers are pending, or ctx is done.
+	// Useful for unit tests to then mutate the current time once everyone is waiting on it.
+	BlockUntil(ctx context.Context, n int) error
+	// WaitForTimer is BlockUntil for a single pending timer
+	WaitForTimer(ctx context.Context) error
+	// Timers describes every pending timer, in the order they will fire
+	Timers() []TimerInfo
+	// Close releases everything waiting on a channel as if its timer fired at the current time, so that nothing waits on
+	// the clock forever: every After, Timer and Sleep receives the time, and every Ticker ticks one last time by the time
+	// Close returns. AfterFunc callbacks that were not yet due never run, so contexts from WithDeadline are left as they
+	// are. Time is frozen from then on. Channels armed later are released straight away, while tickers never tick again
+	// and callbacks never run. Anything returning an error returns ErrClockClosed, including Close itself.
+	Close() error
 
 	Clock
 }
@@ -36,6 +75,11 @@ type SettableClock interface {
 // TimeWarpableClock is a Clock that can tick faster or slower than real time
 type TimeWarpableClock interface {
 	SetWarpSpeed(ratio float64) error
+	// Pause freezes simulated time. Pending timers stay queued but will not fire until resumed, unless the time is
+	// moved explicitly with SetNow, Add or the like.
+	Pause()
+	// Resume continues simulated time from where it was paused, at the current warp speed
+	Resume()
 
 	SettableClock
 }
@@ -45,21 +89,43 @@ func NewRealClock() Clock {
 	return realtime{}
 }
 
-// NewSimulatedClock returns a clock that can be set to a specific time
-func NewSettableClock() SettableClock {
+// NewSettableClock returns a clock that can be set to a specific time. Only WithStartTime, WithTimingWheel and
+// WithTimerStacks apply, and it panics if given WithWarpSpeed or WithBaseClock.
+func NewSettableClock(opts ...Option) SettableClock {
+	o := newOptions(opts)
+	if o.warpableOnly != "" {
+		panic(o.warpableOnly + " does not apply to NewSettableClock")
+	}
+
+	now := time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC) // Obviously the start of the universe
+	if !o.start.IsZero() {
+		now = o.start
+	}
+
 	return &faketime{
-		now:        time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC), // Obviously the start of the universe
-		timers:     queue.NewTimeQueue(),
-		timerAdded: make(chan struct{}, 1),
+		now:    now,
+		timers: o.newTimeQueue(),
+		stacks: o.stacks,
 	}
 }
 
-// NewTimeWarpableClock returns a clock set to the current time with no warping
-func NewTimeWarpableClock() TimeWarpableClock {
+// NewTimeWarpableClock returns a clock set to the current time with no warping, unless configured otherwise by opts
+func NewTimeWarpableClock(opts ...Option) TimeWarpableClock {
+	o := newOptions(opts)
+
+	start := o.base.Now()
+	var drift time.Duration
+	if !o.start.IsZero() {
+		drift = o.start.Sub(start)
+	}
+
 	return &simulation{
-		c:      NewRealClock(),
-		start:  time.Now(),
-		ratio:  1,
-		timers: queue.NewTimeQueue(),
+		c:      o.base,
+		start:  start,
+		drift:  drift,
+		ratio:  o.ratio,
+		timers: o.newTimeQueue(),
+		stacks: o.stacks,
+		wake:   make(chan struct{}, 1),
 	}
 }
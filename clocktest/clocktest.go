@@ -0,0 +1,331 @@
+// Package clocktest checks that a gotime.Clock behaves like the standard library's time functions.
+package clocktest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+// Unit is the smallest duration the conformance tests wait on. It is long enough for clocks that follow real time to
+// be reliable.
+const Unit = 25 * time.Millisecond
+
+// Factory returns a fresh clock to test along with advance, which moves it forward by at least d. advance may be nil
+// for clocks that follow real time, in which case the tests simply wait.
+type Factory func(t *testing.T) (c gotime.Clock, advance func(d time.Duration))
+
+// RunConformance runs every conformance test as a subtest of t against clocks made by factory
+func RunConformance(t *testing.T, factory Factory) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, advance := factory(t)
+			if advance == nil {
+				advance = func(d time.Duration) { time.Sleep(d + Unit/2) }
+			}
+			tt.test(t, c, advance)
+		})
+	}
+}
+
+var tests = []struct {
+	name string
+	test func(t *testing.T, c gotime.Clock, advance func(d time.Duration))
+}{
+	{name: "Now", test: testNow},
+	{name: "Since", test: testSince},
+	{name: "Until", test: testUntil},
+	{name: "After", test: testAfter},
+	{name: "After ordering", test: testAfterOrdering},
+	{name: "Sleep", test: testSleep},
+	{name: "zero and negative durations", test: testNonPositive},
+	{name: "Timer stop pending", test: testTimerStopPending},
+	{name: "Timer stop fired", test: testTimerStopFired},
+	{name: "Timer stop received", test: testTimerStopReceived},
+	{name: "Timer reset pending", test: testTimerResetPending},
+	{name: "Timer reset fired", test: testTimerResetFired},
+	{name: "Timer reset received", test: testTimerResetReceived},
+	{name: "Timer reset stopped", test: testTimerResetStopped},
+	{name: "AfterFunc", test: testAfterFunc},
+	{name: "concurrency", test: testConcurrency},
+}
+
+func testNow(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	start := c.Now()
+	if now := c.Now(); now.Before(start) {
+		t.Errorf("got %s, want no earlier than %s", now, start)
+	}
+
+	advance(Unit)
+	if now, want := c.Now(), start.Add(Unit); now.Before(want) {
+		t.Errorf("got %s, want no earlier than %s", now, want)
+	}
+}
+
+func testSince(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	start := c.Now()
+	if d := c.Since(start); d < 0 {
+		t.Errorf("got %s, want no less than 0", d)
+	}
+
+	advance(Unit)
+	if d := c.Since(start); d < Unit {
+		t.Errorf("got %s, want no less than %s", d, Unit)
+	}
+}
+
+func testUntil(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	end := c.Now().Add(2 * Unit)
+	if d := c.Until(end); d > 2*Unit {
+		t.Errorf("got %s, want no more than %s", d, 2*Unit)
+	}
+
+	advance(Unit)
+	if d := c.Until(end); d > Unit {
+		t.Errorf("got %s, want no more than %s", d, Unit)
+	}
+}
+
+func testAfter(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	start := c.Now()
+	ch := c.After(3 * Unit)
+
+	advance(Unit)
+	expectNoValue(t, ch)
+
+	advance(3 * Unit)
+	got := expectValue(t, ch)
+	if want := start.Add(3 * Unit); got.Before(want) {
+		t.Errorf("got %s, want no earlier than %s", got, want)
+	}
+	expectNoValue(t, ch)
+}
+
+func testAfterOrdering(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	late := c.After(4 * Unit)
+	early := c.After(Unit)
+
+	advance(2 * Unit)
+	expectValue(t, early)
+	expectNoValue(t, late)
+
+	advance(3 * Unit)
+	expectValue(t, late)
+}
+
+func testSleep(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	start := c.Now()
+
+	done := make(chan time.Time, 1)
+	go func() {
+		c.Sleep(2 * Unit)
+		done <- c.Now()
+	}()
+
+	// The sleeper may not have armed its timer yet, so keep time moving until it wakes up
+	for i := 0; i < 20; i++ {
+		advance(Unit)
+
+		select {
+		case got := <-done:
+			if want := start.Add(2 * Unit); got.Before(want) {
+				t.Errorf("woke at %s, want no earlier than %s", got, want)
+			}
+			return
+		case <-time.After(Unit / 4):
+		}
+	}
+	t.Error("sleep never woke up")
+}
+
+func testNonPositive(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	for _, d := range []time.Duration{0, -Unit} {
+		expectValue(t, c.After(d))
+		expectValue(t, c.Timer(d).C())
+
+		called := make(chan time.Time, 1)
+		c.AfterFunc(d, func() { called <- time.Now() })
+		expectValue(t, called)
+
+		done := make(chan time.Time, 1)
+		go func() {
+			c.Sleep(d)
+			done <- time.Now()
+		}()
+		expectValue(t, done)
+	}
+}
+
+func testTimerStopPending(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	timer := c.Timer(2 * Unit)
+	if !timer.Stop() {
+		t.Error("got false stopping a pending timer, want true")
+	}
+	if timer.Stop() {
+		t.Error("got true stopping a stopped timer, want false")
+	}
+
+	advance(3 * Unit)
+	expectNoValue(t, timer.C())
+}
+
+func testTimerStopFired(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	timer := c.Timer(Unit)
+	advance(2 * Unit)
+
+	// The value was never received, so stopping still prevents it
+	if !timer.Stop() {
+		t.Error("got false stopping an unreceived timer, want true")
+	}
+	expectNoValue(t, timer.C())
+}
+
+func testTimerStopReceived(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	timer := c.Timer(Unit)
+	advance(2 * Unit)
+	expectValue(t, timer.C())
+
+	if timer.Stop() {
+		t.Error("got true stopping a received timer, want false")
+	}
+	expectNoValue(t, timer.C())
+}
+
+func testTimerResetPending(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	timer := c.Timer(2 * Unit)
+	if !timer.Reset(6 * Unit) {
+		t.Error("got false resetting a pending timer, want true")
+	}
+
+	advance(3 * Unit)
+	expectNoValue(t, timer.C())
+	advance(4 * Unit)
+	expectValue(t, timer.C())
+}
+
+func testTimerResetFired(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	timer := c.Timer(Unit)
+	advance(2 * Unit)
+
+	if !timer.Reset(2 * Unit) {
+		t.Error("got false resetting an unreceived timer, want true")
+	}
+	expectNoValue(t, timer.C())
+
+	advance(3 * Unit)
+	expectValue(t, timer.C())
+}
+
+func testTimerResetReceived(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	timer := c.Timer(Unit)
+	advance(2 * Unit)
+	expectValue(t, timer.C())
+
+	if timer.Reset(2 * Unit) {
+		t.Error("got true resetting a received timer, want false")
+	}
+
+	advance(3 * Unit)
+	expectValue(t, timer.C())
+}
+
+func testTimerResetStopped(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	timer := c.Timer(Unit)
+	timer.Stop()
+
+	if timer.Reset(Unit) {
+		t.Error("got true resetting a stopped timer, want false")
+	}
+
+	advance(2 * Unit)
+	expectValue(t, timer.C())
+}
+
+func testAfterFunc(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	called := make(chan time.Time, 2)
+	timer := c.AfterFunc(Unit, func() { called <- time.Now() })
+	if timer.C() != nil {
+		t.Error("got channel, want nil")
+	}
+
+	if !timer.Reset(3 * Unit) {
+		t.Error("got false resetting a pending timer, want true")
+	}
+	advance(Unit)
+	expectNoValue(t, called)
+
+	advance(3 * Unit)
+	expectValue(t, called)
+	if timer.Stop() {
+		t.Error("got true stopping a fired timer, want false")
+	}
+
+	if timer.Reset(Unit) {
+		t.Error("got true resetting a fired timer, want false")
+	}
+	if !timer.Stop() {
+		t.Error("got false stopping a pending timer, want true")
+	}
+	advance(2 * Unit)
+	expectNoValue(t, called)
+}
+
+func testConcurrency(t *testing.T, c gotime.Clock, advance func(d time.Duration)) {
+	const n = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			d := time.Duration(i%4+1) * Unit
+			timer := c.Timer(d)
+			timer.Reset(d)
+			c.After(d)
+			c.AfterFunc(d, func() {}).Stop()
+			c.Since(c.Now())
+			timer.Stop()
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for {
+		advance(Unit)
+
+		select {
+		case <-done:
+			return
+		case <-time.After(Unit / 4):
+		}
+	}
+}
+
+func expectValue(t *testing.T, ch <-chan time.Time) time.Time {
+	t.Helper()
+
+	select {
+	case got := <-ch:
+		return got
+	case <-time.After(10 * Unit):
+		t.Error("got nothing, want value")
+		return time.Time{}
+	}
+}
+
+func expectNoValue(t *testing.T, ch <-chan time.Time) {
+	t.Helper()
+
+	select {
+	case got := <-ch:
+		t.Errorf("got %s, want nothing", got)
+	case <-time.After(Unit / 4):
+	}
+}
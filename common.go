@@ -1,6 +1,12 @@
 package gotime
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mgb/gotime/internal/queue"
+)
 
 var (
 	// ErrNegativeRatio is returned when the ratio is negative
@@ -8,4 +14,88 @@ var (
 
 	// ErrTimeInPast is returned when the time is in the past
 	ErrTimeInPast = errors.New("time cannot go backwards")
+
+	// ErrReplayDiverged is what ReplayClock panics with when calls stray from the recording
+	ErrReplayDiverged = errors.New("replay diverged from recording")
+
+	// ErrClockClosed is returned by a clock once it has been closed
+	ErrClockClosed = errors.New("clock closed")
 )
+
+// timerWatch lets goroutines wait for the set of pending timers to change. Not concurrent safe, guard it with the
+// clock's lock.
+type timerWatch struct {
+	changed chan struct{}
+	// Set once the clock is closed, after which nothing changes
+	closed bool
+}
+
+// notify wakes everyone waiting for a change
+func (w *timerWatch) notify() {
+	if w.changed != nil {
+		close(w.changed)
+		w.changed = nil
+	}
+}
+
+// close wakes everyone waiting for good, as the clock is closed
+func (w *timerWatch) close() {
+	w.closed = true
+	w.notify()
+}
+
+// wait returns a channel that is closed on the next change
+func (w *timerWatch) wait() <-chan struct{} {
+	if w.changed == nil {
+		w.changed = make(chan struct{})
+	}
+	return w.changed
+}
+
+// blockUntil waits until pending reports exactly n timers, or the clock is closed. lock and unlock must guard both
+// pending and w.
+func blockUntil(ctx context.Context, n int, w *timerWatch, pending func() int, lock, unlock func()) error {
+	for {
+		lock()
+		if w.closed {
+			unlock()
+			return ErrClockClosed
+		}
+		if pending() == n {
+			unlock()
+			return nil
+		}
+		changed := w.wait()
+		unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// releaseTimers empties the queue of a clock being closed, sending now to every timer with a channel so that nothing
+// waits on it forever. AfterFunc callbacks are dropped, as they never came due, while tickers are returned for the
+// caller to tick one last time once it has let go of the lock.
+// Must be called while holding the clock's lock.
+func releaseTimers(timers queue.TimeQueue[*timerEntry], now time.Time) []*timerEntry {
+	var last time.Time
+	for _, e := range timers.Values() {
+		if e.deadline.After(last) {
+			last = e.deadline
+		}
+	}
+
+	var tickers []*timerEntry
+	for _, e := range timers.PopBeforeOrEqual(last) {
+		switch {
+		case e.kind == KindTicker:
+			tickers = append(tickers, e)
+		case e.f == nil:
+			e.ch <- now
+		}
+	}
+	return tickers
+}
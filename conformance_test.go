@@ -0,0 +1,81 @@
+package gotime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mgb/gotime"
+	"github.com/mgb/gotime/clocktest"
+)
+
+func TestConformance(t *testing.T) {
+	tests := []struct {
+		name     string
+		realtime bool
+		factory  clocktest.Factory
+	}{
+		{
+			name:     "realtime",
+			realtime: true,
+			factory: func(t *testing.T) (gotime.Clock, func(d time.Duration)) {
+				return gotime.NewRealClock(), nil
+			},
+		},
+		{
+			name: "settable",
+			factory: func(t *testing.T) (gotime.Clock, func(d time.Duration)) {
+				c := gotime.NewSettableClock()
+				return c, func(d time.Duration) { c.Add(d) }
+			},
+		},
+		{
+			name: "settable timing wheel",
+			factory: func(t *testing.T) (gotime.Clock, func(d time.Duration)) {
+				c := gotime.NewSettableClock(gotime.WithTimingWheel(time.Millisecond))
+				return c, func(d time.Duration) { c.Add(d) }
+			},
+		},
+		{
+			name: "warpable",
+			factory: func(t *testing.T) (gotime.Clock, func(d time.Duration)) {
+				sim := gotime.NewTimeWarpableClock(gotime.WithBaseClock(gotime.NewSettableClock()))
+				return sim, func(d time.Duration) { sim.Add(d) }
+			},
+		},
+		{
+			name: "warpable driven by its base clock",
+			factory: func(t *testing.T) (gotime.Clock, func(d time.Duration)) {
+				f := gotime.NewSettableClock()
+				sim := gotime.NewTimeWarpableClock(gotime.WithBaseClock(f), gotime.WithWarpSpeed(60))
+				return sim, func(d time.Duration) {
+					// Round up so that simulated time moves by at least d
+					f.Add((d + 59) / 60)
+				}
+			},
+		},
+		{
+			name: "warpable timing wheel",
+			factory: func(t *testing.T) (gotime.Clock, func(d time.Duration)) {
+				sim := gotime.NewTimeWarpableClock(gotime.WithBaseClock(gotime.NewSettableClock()), gotime.WithTimingWheel(time.Millisecond))
+				return sim, func(d time.Duration) { sim.Add(d) }
+			},
+		},
+		{
+			name:     "warpable real time",
+			realtime: true,
+			factory: func(t *testing.T) (gotime.Clock, func(d time.Duration)) {
+				return gotime.NewTimeWarpableClock(), nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.realtime && testing.Short() {
+				t.Skip("skipping test in short mode.")
+			}
+			t.Parallel()
+
+			clocktest.RunConformance(t, tt.factory)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package gotime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithDeadline returns a copy of parent that is done once clock reaches d, like context.WithDeadline does for real time.
+// Cancelling parent cancels the returned context as well. The returned CancelFunc releases the clock's timer and
+// should be called as soon as the work using the context is complete.
+func WithDeadline(parent context.Context, clock Clock, d time.Time) (context.Context, context.CancelFunc) {
+	ctx := &clockContext{
+		Context:  parent,
+		deadline: d,
+		done:     make(chan struct{}),
+	}
+	cancel := func() { ctx.cancel(context.Canceled) }
+
+	if err := parent.Err(); err != nil {
+		ctx.cancel(err)
+		return ctx, cancel
+	}
+
+	dur := clock.Until(d)
+	if dur <= 0 {
+		ctx.cancel(context.DeadlineExceeded)
+		return ctx, cancel
+	}
+
+	timer := clock.AfterFunc(dur, func() { ctx.cancel(context.DeadlineExceeded) })
+	go func() {
+		defer timer.Stop()
+
+		select {
+		case <-parent.Done():
+			ctx.cancel(parent.Err())
+		case <-ctx.done:
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// WithTimeout returns WithDeadline(parent, clock, clock.Now().Add(timeout))
+func WithTimeout(parent context.Context, clock Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return WithDeadline(parent, clock, clock.Now().Add(timeout))
+}
+
+// clockContext is a context.Context with a deadline tracked by a Clock
+type clockContext struct {
+	context.Context
+
+	deadline time.Time
+	done     chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func (c *clockContext) String() string {
+	return fmt.Sprintf("%v.WithDeadline(%s)", c.Context, c.deadline)
+}
+
+// Deadline is the earlier of the parent's deadline and the clock's, as with context.WithDeadline
+func (c *clockContext) Deadline() (time.Time, bool) {
+	if parent, ok := c.Context.Deadline(); ok && parent.Before(c.deadline) {
+		return parent, true
+	}
+	return c.deadline, true
+}
+
+func (c *clockContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *clockContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// cancel closes done, recording err, unless it was already cancelled
+func (c *clockContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+}
@@ -0,0 +1,220 @@
+package gotime
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	c := NewSettableClock()
+
+	ctx, cancel := WithTimeout(context.Background(), c, time.Second)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Error("got no deadline, want one")
+	}
+	if want := c.Now().Add(time.Second); deadline != want {
+		t.Errorf("got %s, want %s", deadline, want)
+	}
+
+	c.Add(time.Second - time.Nanosecond)
+	select {
+	case <-ctx.Done():
+		t.Error("got done, want nothing")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if err := ctx.Err(); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+
+	c.Add(time.Nanosecond)
+	select {
+	case <-ctx.Done():
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("context took too long to be done")
+	}
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestWithTimeout_Close(t *testing.T) {
+	tests := []struct {
+		name  string
+		clock SettableClock
+	}{
+		{name: "settable", clock: NewSettableClock()},
+		{name: "warpable", clock: NewTimeWarpableClock(WithBaseClock(NewSettableClock()))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.clock
+
+			ctx, cancel := WithTimeout(context.Background(), c, time.Hour)
+			defer cancel()
+
+			// Closing the clock is not the deadline passing
+			if err := c.Close(); err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+			select {
+			case <-ctx.Done():
+				t.Errorf("got done with %v, want nothing", ctx.Err())
+			case <-time.After(10 * time.Millisecond):
+			}
+
+			cancel()
+			if err := ctx.Err(); err != context.Canceled {
+				t.Errorf("got %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	type key struct{}
+
+	tests := []struct {
+		name    string
+		parent  func() (context.Context, context.CancelFunc)
+		offset  time.Duration
+		cancel  bool
+		wantErr error
+	}{
+		{
+			name:    "deadline in the past",
+			parent:  func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			offset:  -time.Second,
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "parent already cancelled",
+			parent: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			offset:  time.Second,
+			wantErr: context.Canceled,
+		},
+		{
+			name:    "cancelled",
+			parent:  func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			offset:  time.Second,
+			cancel:  true,
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSettableClock()
+
+			parent, parentCancel := tt.parent()
+			defer parentCancel()
+			parent = context.WithValue(parent, key{}, tt.name)
+
+			ctx, cancel := WithDeadline(parent, c, c.Now().Add(tt.offset))
+			defer cancel()
+
+			if v := ctx.Value(key{}); v != tt.name {
+				t.Errorf("got value %v, want %v", v, tt.name)
+			}
+
+			if tt.cancel {
+				cancel()
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("context took too long to be done")
+			}
+			if err := ctx.Err(); err != tt.wantErr {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+
+			// The timer is released once the context is done
+			waitForTimers(t, c, 0)
+		})
+	}
+}
+
+func TestWithDeadline_ParentCancel(t *testing.T) {
+	c := NewSettableClock()
+
+	parent, parentCancel := context.WithCancel(context.Background())
+	ctx, cancel := WithDeadline(parent, c, c.Now().Add(time.Hour))
+	defer cancel()
+
+	parentCancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("context took too long to be done")
+	}
+	if err := ctx.Err(); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestWithTimeout_Nested(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent time.Duration
+		child  time.Duration
+		want   time.Duration
+	}{
+		{name: "parent earlier", parent: time.Second, child: time.Hour, want: time.Second},
+		{name: "child earlier", parent: time.Hour, child: time.Second, want: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSettableClock()
+			start := c.Now()
+
+			parent, parentCancel := WithTimeout(context.Background(), c, tt.parent)
+			defer parentCancel()
+			ctx, cancel := WithTimeout(parent, c, tt.child)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Error("got no deadline, want one")
+			}
+			if want := start.Add(tt.want); deadline != want {
+				t.Errorf("got %s, want %s", deadline, want)
+			}
+
+			c.Add(tt.want)
+			select {
+			case <-ctx.Done():
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("context took too long to be done")
+			}
+			if err := ctx.Err(); err != context.DeadlineExceeded {
+				t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+			}
+		})
+	}
+}
+
+func TestWithTimeout_Warped(t *testing.T) {
+	sim, f := newTimeWarpableClockWithFake(t)
+	sim.SetWarpSpeed(60)
+
+	ctx, cancel := WithTimeout(context.Background(), sim, time.Minute)
+	defer cancel()
+
+	f.Add(time.Second)
+	select {
+	case <-ctx.Done():
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("context took too long to be done")
+	}
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,74 @@
+package gotime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Deadline is a point in time on a Clock, useful for tracking the time budget of a request
+type Deadline struct {
+	c Clock
+	t time.Time
+
+	done     chan struct{}
+	doneOnce sync.Once
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDeadline returns a Deadline at t, as measured by c
+func NewDeadline(c Clock, t time.Time) *Deadline {
+	return &Deadline{
+		c:    c,
+		t:    t,
+		done: make(chan struct{}),
+		stop: make(chan struct{}),
+	}
+}
+
+func (d *Deadline) String() string {
+	return fmt.Sprintf("deadline{at: %s, remaining: %s}", d.t, d.Remaining())
+}
+
+// Time returns when the deadline expires
+func (d *Deadline) Time() time.Time {
+	return d.t
+}
+
+// Remaining returns how long until the deadline expires, or 0 if it already has
+func (d *Deadline) Remaining() time.Duration {
+	if r := d.c.Until(d.t); r > 0 {
+		return r
+	}
+	return 0
+}
+
+// Expired reports whether the clock has reached the deadline
+func (d *Deadline) Expired() bool {
+	return !d.c.Now().Before(d.t)
+}
+
+// Done returns a channel that is closed once the deadline expires. The clock's timer is only armed on the first call,
+// call Stop to release it early.
+func (d *Deadline) Done() <-chan struct{} {
+	d.doneOnce.Do(func() {
+		timer := d.c.Timer(d.c.Until(d.t))
+		go func() {
+			select {
+			case <-timer.C():
+				close(d.done)
+			case <-d.stop:
+				timer.Stop()
+			}
+		}()
+	})
+	return d.done
+}
+
+// Stop releases the timer behind Done. If the deadline has not expired yet, Done will never be closed.
+func (d *Deadline) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+}
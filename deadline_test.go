@@ -0,0 +1,78 @@
+package gotime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadline(t *testing.T) {
+	c := NewSettableClock()
+	d := NewDeadline(c, c.Now().Add(time.Minute))
+	defer d.Stop()
+
+	if r := d.Remaining(); r != time.Minute {
+		t.Errorf("got %s, want %s", r, time.Minute)
+	}
+	if d.Expired() {
+		t.Error("got expired, want not expired")
+	}
+
+	done := d.Done()
+	waitForTimers(t, c, 1)
+
+	c.Add(59 * time.Second)
+	if r := d.Remaining(); r != time.Second {
+		t.Errorf("got %s, want %s", r, time.Second)
+	}
+	select {
+	case <-done:
+		t.Error("got done, want nothing")
+	default:
+	}
+
+	c.Add(2 * time.Second)
+	if r := d.Remaining(); r != 0 {
+		t.Errorf("got %s, want 0", r)
+	}
+	if !d.Expired() {
+		t.Error("got not expired, want expired")
+	}
+	select {
+	case <-done:
+	case <-time.After(50 * time.Millisecond):
+		t.Error("deadline took too long to be done")
+	}
+}
+
+func TestDeadline_Stop(t *testing.T) {
+	c := NewSettableClock()
+	d := NewDeadline(c, c.Now().Add(time.Minute))
+
+	done := d.Done()
+	waitForTimers(t, c, 1)
+
+	d.Stop()
+	waitForTimers(t, c, 0)
+
+	c.Add(time.Hour)
+	select {
+	case <-done:
+		t.Error("got done, want nothing")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestUntil(t *testing.T) {
+	c := NewSettableClock()
+	end := c.Now().Add(time.Hour)
+
+	sim, _ := newTimeWarpableClockWithFake(t)
+	sim.SetNow(c.Now())
+	sim.SetWarpSpeed(60)
+
+	for _, clock := range []Clock{c, sim} {
+		if d := clock.Until(end); d != time.Hour {
+			t.Errorf("%T: got %s, want %s", clock, d, time.Hour)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package gotime
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// defaultClock holds a clockHolder, as atomic.Value needs every value stored to be the same concrete type
+var defaultClock atomic.Value
+
+type clockHolder struct {
+	Clock
+}
+
+func init() {
+	defaultClock.Store(clockHolder{NewRealClock()})
+}
+
+// Default returns the process-wide clock used by the package-level time functions, NewRealClock unless replaced by
+// SetDefault
+func Default() Clock {
+	return defaultClock.Load().(clockHolder).Clock
+}
+
+// SetDefault replaces the process-wide clock, returning a function that puts back the previous one. Useful for tests
+// of code that calls the package-level time functions instead of taking a Clock.
+//
+//	restore := gotime.SetDefault(gotime.NewSettableClock())
+//	defer restore()
+func SetDefault(c Clock) (restore func()) {
+	prev := defaultClock.Swap(clockHolder{c})
+	return func() {
+		defaultClock.Store(prev)
+	}
+}
+
+// Now is time.Now for the default clock
+func Now() time.Time {
+	return Default().Now()
+}
+
+// Since is time.Since for the default clock
+func Since(t time.Time) time.Duration {
+	return Default().Since(t)
+}
+
+// Until is time.Until for the default clock
+func Until(t time.Time) time.Duration {
+	return Default().Until(t)
+}
+
+// After is time.After for the default clock
+func After(d time.Duration) <-chan time.Time {
+	return Default().After(d)
+}
+
+// Sleep is time.Sleep for the default clock
+func Sleep(d time.Duration) {
+	Default().Sleep(d)
+}
+
+// NewTimer is time.NewTimer for the default clock
+func NewTimer(d time.Duration) Timer {
+	return Default().Timer(d)
+}
@@ -0,0 +1,48 @@
+package gotime
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetDefault(t *testing.T) {
+	if _, ok := Default().(realtime); !ok {
+		t.Fatalf("got %T, want realtime", Default())
+	}
+
+	c := NewSettableClock()
+	c.SetNow(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	restore := SetDefault(c)
+	if now := Now(); now != c.Now() {
+		t.Errorf("got %s, want %s", now, c.Now())
+	}
+
+	start := Now()
+	ch := After(time.Second)
+	timer := NewTimer(time.Minute)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		Sleep(time.Hour)
+	}()
+	waitForTimers(t, c, 3)
+
+	c.Add(time.Hour)
+	wg.Wait()
+	<-ch
+	<-timer.C()
+
+	if d := Since(start); d != time.Hour {
+		t.Errorf("got %s, want %s", d, time.Hour)
+	}
+
+	restore()
+	if _, ok := Default().(realtime); !ok {
+		t.Errorf("got %T, want realtime", Default())
+	}
+}
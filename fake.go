@@ -1,6 +1,7 @@
 package gotime
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -10,10 +11,16 @@ import (
 
 type faketime struct {
 	now    time.Time
-	timers queue.TimeQueue
+	timers queue.TimeQueue[*timerEntry]
+	// Whether to capture the stack of every timer armed, for Timers
+	stacks bool
+	// The seq given to the next timer armed or reset
+	seq uint64
 
-	// Useful for unit tests, this buffered channel will signal when at least one timer was added since it was last read
-	timerAdded chan struct{}
+	// Signals changes to timers for BlockUntil
+	watch timerWatch
+
+	closed bool
 
 	sync.RWMutex
 }
@@ -26,6 +33,10 @@ func (f *faketime) Add(d time.Duration) time.Time {
 	f.Lock()
 	defer f.Unlock()
 
+	if f.closed {
+		return f.now
+	}
+
 	old := f.now
 	f.now = f.now.Add(d)
 
@@ -38,6 +49,10 @@ func (f *faketime) SetNow(t time.Time) time.Time {
 	f.Lock()
 	defer f.Unlock()
 
+	if f.closed {
+		return f.now
+	}
+
 	old := f.now
 	f.now = t
 
@@ -46,29 +61,226 @@ func (f *faketime) SetNow(t time.Time) time.Time {
 	return old
 }
 
+func (f *faketime) AddStrict(d time.Duration) (time.Time, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	old := f.now
+	if f.closed {
+		return old, ErrClockClosed
+	}
+	if d < 0 {
+		return old, ErrTimeInPast
+	}
+	f.now = f.now.Add(d)
+
+	f.triggerTimers(f.now)
+
+	return old, nil
+}
+
+func (f *faketime) SetNowStrict(t time.Time) (time.Time, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	old := f.now
+	if f.closed {
+		return old, ErrClockClosed
+	}
+	if t.Before(old) {
+		return old, ErrTimeInPast
+	}
+	f.now = t
+
+	f.triggerTimers(f.now)
+
+	return old, nil
+}
+
 func (f *faketime) triggerTimers(t time.Time) {
-	// Trigger any timer that would pop with the new time
-	for _, c := range f.timers.PopBeforeOrEqual(t) {
-		c <- t
+	// Trigger any timer that would pop with the new time. Each callback gets a goroutine of its own, started in the order
+	// the timers were armed, so that one can wait on another.
+	for _, fn := range f.popTimers(t) {
+		go fn()
+	}
+}
+
+// popTimers sends t to every timer due by t, returning the AfterFunc callbacks that are due for the caller to run.
+// Must only be used when holding the lock.
+func (f *faketime) popTimers(t time.Time) []func() {
+	var fns []func()
+	popped := f.timers.PopBeforeOrEqual(t)
+	for _, e := range popped {
+		if e.f != nil {
+			fns = append(fns, e.f)
+			continue
+		}
+		e.ch <- t
+	}
+	if len(popped) > 0 {
+		f.watch.notify()
+	}
+	return fns
+}
+
+func (f *faketime) AdvanceToNext() (time.Time, bool) {
+	f.RLock()
+	next, ok := f.timers.Peek()
+	f.RUnlock()
+
+	if !ok {
+		return f.Now(), false
+	}
+	return f.advance(next)
+}
+
+func (f *faketime) RunUntil(t time.Time) {
+	for {
+		if _, ok := f.advance(t); !ok {
+			break
+		}
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if t.After(f.now) && !f.closed {
+		f.now = t
 	}
 }
 
+// advance moves time to the earliest pending timer, as long as it is not after until, and fires every timer due at it.
+// Callbacks are run before returning so that any timers they arm are seen by the next call.
+func (f *faketime) advance(until time.Time) (time.Time, bool) {
+	f.Lock()
+
+	next, ok := f.timers.Peek()
+	if !ok || next.After(until) {
+		f.Unlock()
+		return time.Time{}, false
+	}
+
+	if next.After(f.now) {
+		f.now = next
+	}
+	fns := f.popTimers(next)
+	f.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+
+	return next, true
+}
+
 func (f *faketime) After(d time.Duration) <-chan time.Time {
+	return f.after(d, KindAfter)
+}
+
+func (f *faketime) after(d time.Duration, kind TimerKind) <-chan time.Time {
+	f.Lock()
+	defer f.Unlock()
+
+	ch := make(chan time.Time, 1)
 	if d <= 0 {
 		// Trigger immediately if in the future
-		ch := make(chan time.Time, 1)
 		ch <- f.now
 		return ch
 	}
 
+	f.lockedAdd(f.now.Add(d), ch, kind, nil)
+
+	return ch
+}
+
+// lockedAdd queues ch to receive the time once t is reached, or fn to be called instead if set. The returned functions
+// remove it again or move it to fire at another time, reporting whether it was still pending, and must also be called
+// while holding the lock.
+// Must only be used when holding the lock.
+func (f *faketime) lockedAdd(t time.Time, ch chan<- time.Time, kind TimerKind, fn func()) (remove func() bool, update func(t time.Time) bool) {
+	if f.closed {
+		// Released straight away, the same as every channel pending when closed. Callbacks never come due, and tickers
+		// would only rearm and tick forever.
+		if ch != nil {
+			ch <- f.now
+		}
+		return func() bool { return false }, func(time.Time) bool { return false }
+	}
+
+	e := newTimerEntry(t, f.now, kind, ch, fn, f.stacks)
+	e.seq = f.seq
+	f.seq++
+	h := f.timers.Add(t, e)
+	f.watch.notify()
+
+	remove = func() bool {
+		removed := f.timers.Remove(h)
+		if removed {
+			f.watch.notify()
+		}
+		return removed
+	}
+	update = func(t time.Time) bool {
+		if !f.timers.Update(h, t) {
+			return false
+		}
+		// Behind any timer already due at t, as the queue now orders it
+		e.deadline = t
+		e.seq = f.seq
+		f.seq++
+		f.watch.notify()
+		return true
+	}
+	return remove, update
+}
+
+// handle wraps the functions returned by lockedAdd into a timerHandle that takes the lock itself
+func (f *faketime) handle(remove func() bool, update func(t time.Time) bool) timerHandle {
+	return timerHandle{
+		cancel: func() bool {
+			f.Lock()
+			defer f.Unlock()
+
+			return remove()
+		},
+		reset: func(d time.Duration) bool {
+			f.Lock()
+			defer f.Unlock()
+
+			return update(f.now.Add(d))
+		},
+	}
+}
+
+func (f *faketime) AfterFunc(d time.Duration, fn func()) Timer {
+	return newFakeTimer(d, nil, func(d time.Duration) timerHandle { return f.scheduleFunc(d, fn) })
+}
+
+// scheduleFunc queues fn to be called once d has elapsed, in its own goroutine unless fired by AdvanceToNext or
+// RunUntil.
+func (f *faketime) scheduleFunc(d time.Duration, fn func()) timerHandle {
 	f.Lock()
 	defer f.Unlock()
 
-	ch := make(chan time.Time, 1)
-	f.timers.Add(f.now.Add(d), ch)
-	f.notifyTimer()
+	return f.lockedScheduleFunc(f.now.Add(d), KindAfterFunc, fn)
+}
 
-	return ch
+// scheduleFuncAt is scheduleFunc for an absolute time, used by tickers
+func (f *faketime) scheduleFuncAt(t time.Time, fn func()) func() bool {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.lockedScheduleFunc(t, KindTicker, fn).cancel
+}
+
+// lockedScheduleFunc must only be used when holding the lock
+func (f *faketime) lockedScheduleFunc(t time.Time, kind TimerKind, fn func()) timerHandle {
+	if !t.After(f.now) && !f.closed {
+		go fn()
+		return firedHandle
+	}
+
+	return f.handle(f.lockedAdd(t, nil, kind, fn))
 }
 
 func (f *faketime) Now() time.Time {
@@ -82,77 +294,110 @@ func (f *faketime) Since(t time.Time) time.Duration {
 	return f.Now().Sub(t)
 }
 
-func (f *faketime) Sleep(d time.Duration) {
-	<-f.After(d)
+func (f *faketime) Until(t time.Time) time.Duration {
+	return t.Sub(f.Now())
 }
 
-func (f *faketime) Timer(d time.Duration) Timer {
-	return f.newTimer(d)
+func (f *faketime) Sleep(d time.Duration) {
+	<-f.after(d, KindSleep)
 }
 
-func (f *faketime) newTimer(d time.Duration) *fakeTimer {
+func (f *faketime) Tick(d time.Duration) <-chan time.Time {
 	if d <= 0 {
-		// Trigger immediately if in the future
-		c := make(chan time.Time, 1)
-		c <- f.now
-		done := make(chan struct{})
-		close(done)
-		return &fakeTimer{
-			c:        c,
-			done:     done,
-			newTimer: f.newTimer,
-		}
+		return nil
 	}
+	return f.Ticker(d).C()
+}
+
+func (f *faketime) Ticker(d time.Duration) Ticker {
+	return newFakeTicker(d, f.Now, f.scheduleFuncAt)
+}
+
+func (f *faketime) Timer(d time.Duration) Timer {
+	c := make(chan time.Time, 1)
+	return newFakeTimer(d, c, func(d time.Duration) timerHandle { return f.scheduleChan(d, c) })
+}
 
+// scheduleChan queues ch to receive the time once d has elapsed.
+func (f *faketime) scheduleChan(d time.Duration, ch chan<- time.Time) timerHandle {
 	f.Lock()
 	defer f.Unlock()
 
-	c := make(chan time.Time, 1)
-	closeCh := make(chan struct{})
-	done := make(chan struct{})
+	if d <= 0 {
+		ch <- f.now
+		return firedHandle
+	}
 
-	ch := make(chan time.Time, 1)
-	cancel := f.timers.Add(f.now.Add(d), ch)
-	f.notifyTimer()
+	return f.handle(f.lockedAdd(f.now.Add(d), ch, KindTimer, nil))
+}
 
-	go func() {
-		defer close(done)
+func (f *faketime) BlockUntil(ctx context.Context, n int) error {
+	return blockUntil(ctx, n, &f.watch, f.timers.Len, f.Lock, f.Unlock)
+}
 
-		select {
-		case now := <-ch:
-			c <- now
-		case <-closeCh:
-			c <- f.Now()
-			cancel()
-		}
-	}()
+func (f *faketime) WaitForTimer(ctx context.Context) error {
+	return f.BlockUntil(ctx, 1)
+}
 
-	return &fakeTimer{
-		c:        c,
-		close:    closeCh,
-		done:     done,
-		newTimer: f.newTimer,
-	}
+func (f *faketime) Timers() []TimerInfo {
+	f.RLock()
+	defer f.RUnlock()
+
+	return timerInfos(f.timers.Values())
 }
 
-func (f *faketime) notifyTimer() {
-	// Notify anyone waiting for a timer to be added in a non-blocking manner.
-	// Useful for unit tests to then mutate the current time after a timer was added.
-	select {
-	case f.timerAdded <- struct{}{}:
-	default:
+func (f *faketime) Close() error {
+	f.Lock()
+	if f.closed {
+		f.Unlock()
+		return ErrClockClosed
 	}
+	f.closed = true
+
+	tickers := releaseTimers(f.timers, f.now)
+	f.watch.close()
+	f.Unlock()
+
+	// Without the lock, as tickers use the clock to rearm
+	for _, e := range tickers {
+		e.f()
+	}
+
+	return nil
 }
 
+// fakeTimer is the Timer for clocks that keep their own timer queue. As with time.Timer since Go 1.23, no stale value
+// is received from C once Stop or Reset returns.
 type fakeTimer struct {
+	// nil for AfterFunc, as with time.AfterFunc
 	c chan time.Time
+	// Arms the timer to fire after d
+	schedule func(d time.Duration) timerHandle
+
+	sync.Mutex
+	handle timerHandle
+}
 
-	// Close this channel to stop the goroutine
-	close chan struct{}
-	// Will be closed after the goroutine is stopped
-	done chan struct{}
+// timerHandle is how a fakeTimer controls what it armed in the clock's queue
+type timerHandle struct {
+	// Disarms the timer, reporting whether it was still pending
+	cancel func() bool
+	// Moves the timer to fire d from now, keeping its entry, reporting whether it was still pending to move
+	reset func(d time.Duration) bool
+}
+
+// firedHandle is the timerHandle of a timer that was never queued, having fired as soon as it was armed
+var firedHandle = timerHandle{
+	cancel: func() bool { return false },
+	reset:  func(time.Duration) bool { return false },
+}
 
-	newTimer func(d time.Duration) *fakeTimer
+func newFakeTimer(d time.Duration, c chan time.Time, schedule func(d time.Duration) timerHandle) *fakeTimer {
+	return &fakeTimer{
+		c:        c,
+		schedule: schedule,
+		handle:   schedule(d),
+	}
 }
 
 func (t *fakeTimer) C() <-chan time.Time {
@@ -160,21 +405,144 @@ func (t *fakeTimer) C() <-chan time.Time {
 }
 
 func (t *fakeTimer) Reset(d time.Duration) bool {
-	newT := t.newTimer(d)
-	t.c = newT.c
-	t.close = newT.close
-	t.done = newT.done
+	t.Lock()
+	defer t.Unlock()
 
-	return false
+	// Still pending, so nothing can have been sent on c. Moving it keeps its place in Timers.
+	if d > 0 && t.handle.reset(d) {
+		return true
+	}
+
+	active := t.stop()
+	t.handle = t.schedule(d)
+
+	return active
 }
 
 func (t *fakeTimer) Stop() bool {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.stop()
+}
+
+// stop must only be used when holding the lock
+func (t *fakeTimer) stop() bool {
+	active := t.handle.cancel()
+
+	// Clocks send on c while holding their own lock, so once cancel has returned any value already sent is sitting here.
+	// As with time.Timer, a value that was never received means the timer was still active.
+	if t.c != nil {
+		select {
+		case <-t.c:
+			active = true
+		default:
+		}
+	}
+
+	return active
+}
+
+// fakeTicker is the Ticker for clocks that keep their own timer queue
+type fakeTicker struct {
+	c        chan time.Time
+	now      func() time.Time
+	schedule func(t time.Time, f func()) func() bool
+
+	sync.Mutex
+	d      time.Duration
+	next   time.Time
+	cancel func() bool
+	// Incremented on every Stop and Reset so that ticks already in flight are ignored
+	generation int
+}
+
+func newFakeTicker(d time.Duration, now func() time.Time, schedule func(t time.Time, f func()) func() bool) *fakeTicker {
+	if d <= 0 {
+		panic("non-positive interval for Ticker")
+	}
+
+	t := &fakeTicker{
+		c:        make(chan time.Time, 1),
+		now:      now,
+		schedule: schedule,
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.start(d)
+
+	return t
+}
+
+// start must only be used when holding the lock
+func (t *fakeTicker) start(d time.Duration) {
+	t.d = d
+	t.next = t.now().Add(d)
+	t.arm()
+}
+
+// arm must only be used when holding the lock
+func (t *fakeTicker) arm() {
+	generation := t.generation
+	t.cancel = t.schedule(t.next, func() { t.tick(generation) })
+}
+
+func (t *fakeTicker) tick(generation int) {
+	t.Lock()
+	defer t.Unlock()
+
+	if generation != t.generation {
+		return
+	}
+
+	// Skip every tick that time has already moved past, the same as time.Ticker does for slow receivers
+	now := t.now()
+	if !t.next.After(now) {
+		t.next = t.next.Add((now.Sub(t.next)/t.d + 1) * t.d)
+	}
+
+	// Rearm before sending so that anyone reading the tick can move time forward again
+	t.arm()
+
 	select {
-	case <-t.done:
-		return true
+	case t.c <- now:
 	default:
 	}
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
 
-	close(t.close)
-	return false
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.stop()
+	t.start(d)
+}
+
+func (t *fakeTicker) Stop() {
+	t.Lock()
+	defer t.Unlock()
+
+	t.stop()
+}
+
+// stop must only be used when holding the lock
+func (t *fakeTicker) stop() {
+	t.generation++
+	t.cancel()
+
+	// Ticks are sent while holding the lock, so draining here leaves no stale tick behind
+	select {
+	case <-t.c:
+	default:
+	}
 }
@@ -1,9 +1,13 @@
 package gotime
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
 	"testing"
+	"testing/quick"
 	"time"
 )
 
@@ -72,38 +76,6 @@ func TestAdd(t *testing.T) {
 	}
 }
 
-func TestAfter(t *testing.T) {
-	c := NewSettableClock()
-
-	ch := c.After(time.Second)
-	select {
-	case <-ch:
-		t.Error("got value, want nothing")
-	default:
-	}
-
-	c.SetNow(time.Now().Add(time.Second))
-	select {
-	case <-ch:
-	default:
-		t.Error("got nothing, want value")
-	}
-
-	ch = c.After(-time.Second)
-	select {
-	case <-ch:
-	default:
-		t.Error("got nothing, want value")
-	}
-
-	ch = c.After(0)
-	select {
-	case <-ch:
-	default:
-		t.Error("got nothing, want value")
-	}
-}
-
 func TestNow(t *testing.T) {
 	c := NewSettableClock()
 	c.SetNow(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
@@ -149,51 +121,707 @@ func TestSince(t *testing.T) {
 	}
 }
 
-func TestSleep(t *testing.T) {
+func TestAfterFunc(t *testing.T) {
+	c := NewSettableClock()
+
+	called := make(chan struct{})
+	timer := c.AfterFunc(time.Second, func() { close(called) })
+	if timer.C() != nil {
+		t.Error("got channel, want nil")
+	}
+
+	c.Add(time.Second - time.Nanosecond)
+	select {
+	case <-called:
+		t.Error("got call, want nothing")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.Add(time.Nanosecond)
+	select {
+	case <-called:
+	case <-time.After(50 * time.Millisecond):
+		t.Error("callback took too long to run")
+	}
+
+	if timer.Stop() {
+		t.Error("got true stopping a fired timer, want false")
+	}
+}
+
+func TestAfterFunc_Stop(t *testing.T) {
+	c := NewSettableClock()
+
+	called := make(chan struct{})
+	timer := c.AfterFunc(time.Second, func() { close(called) })
+
+	if !timer.Stop() {
+		t.Error("got false stopping a pending timer, want true")
+	}
+	if timer.Stop() {
+		t.Error("got true stopping a stopped timer, want false")
+	}
+
+	c.Add(time.Second)
+	select {
+	case <-called:
+		t.Error("got call, want nothing")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestAfterFunc_Reset(t *testing.T) {
+	c := NewSettableClock()
+
+	called := make(chan struct{})
+	timer := c.AfterFunc(time.Second, func() { close(called) })
+
+	if !timer.Reset(time.Minute) {
+		t.Error("got false resetting a pending timer, want true")
+	}
+
+	c.Add(time.Second)
+	select {
+	case <-called:
+		t.Error("got call, want nothing")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.Add(time.Minute)
+	select {
+	case <-called:
+	case <-time.After(50 * time.Millisecond):
+		t.Error("callback took too long to run")
+	}
+}
+
+func TestAfterFunc_WaitOnAnother(t *testing.T) {
+	tests := []struct {
+		name  string
+		clock func(t *testing.T) (c Clock, advance func(d time.Duration))
+	}{
+		{
+			name: "settable",
+			clock: func(t *testing.T) (Clock, func(d time.Duration)) {
+				c := NewSettableClock()
+				return c, func(d time.Duration) { c.Add(d) }
+			},
+		},
+		{
+			name: "warpable",
+			clock: func(t *testing.T) (Clock, func(d time.Duration)) {
+				sim := NewTimeWarpableClock(WithBaseClock(NewSettableClock()))
+				return sim, func(d time.Duration) { sim.Add(d) }
+			},
+		},
+		{
+			// Fired by the dispatcher
+			name: "warpable base clock",
+			clock: func(t *testing.T) (Clock, func(d time.Duration)) {
+				sim, f := newTimeWarpableClockWithFake(t)
+				return sim, func(d time.Duration) { f.Add(d) }
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, advance := tt.clock(t)
+
+			// Fired together, the first callback waits for the second, as it could with time.AfterFunc
+			second := make(chan struct{})
+			done := make(chan struct{})
+			c.AfterFunc(time.Second, func() {
+				<-second
+				close(done)
+			})
+			c.AfterFunc(time.Second, func() { close(second) })
+
+			advance(time.Second)
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Error("first callback still waiting on the second")
+			}
+		})
+	}
+}
+
+func TestTicker(t *testing.T) {
+	c := NewSettableClock()
+
+	ticker := c.Ticker(time.Second)
+	defer ticker.Stop()
+
+	expectTick := func(want time.Time) {
+		t.Helper()
+
+		select {
+		case got := <-ticker.C():
+			if got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Error("ticker took too long to trigger")
+		}
+	}
+	expectNoTick := func() {
+		t.Helper()
+
+		select {
+		case got := <-ticker.C():
+			t.Errorf("got %s, want nothing", got)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	expectNoTick()
+
+	c.Add(time.Second)
+	expectTick(c.Now())
+
+	// Ticks for a slow receiver are dropped
+	c.Add(5 * time.Second)
+	expectTick(c.Now())
+	expectNoTick()
+
+	c.Add(time.Second)
+	expectTick(c.Now())
+
+	ticker.Reset(time.Minute)
+	c.Add(time.Second)
+	expectNoTick()
+	c.Add(time.Minute - time.Second)
+	expectTick(c.Now())
+
+	ticker.Stop()
+	c.Add(time.Hour)
+	expectNoTick()
+}
+
+func TestTick(t *testing.T) {
+	c := NewSettableClock()
+
+	if ch := c.Tick(0); ch != nil {
+		t.Error("got channel, want nil")
+	}
+
+	ch := c.Tick(time.Second)
+	for i := 0; i < 3; i++ {
+		c.Add(time.Second)
+
+		select {
+		case <-ch:
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("tick took too long to trigger")
+		}
+	}
+}
+
+func TestAdvanceToNext(t *testing.T) {
 	c := NewSettableClock()
-	f := c.(*faketime)
+	start := c.Now()
+
+	if _, ok := c.AdvanceToNext(); ok {
+		t.Error("got true with no timers, want false")
+	}
+	if now := c.Now(); now != start {
+		t.Errorf("got %s, want %s", now, start)
+	}
+
+	ch := c.After(5 * time.Second)
+	c.After(time.Minute)
+
+	next, ok := c.AdvanceToNext()
+	if !ok {
+		t.Fatal("got false, want true")
+	}
+	if want := start.Add(5 * time.Second); next != want {
+		t.Errorf("got %s, want %s", next, want)
+	}
+	if now := c.Now(); now != next {
+		t.Errorf("got %s, want %s", now, next)
+	}
 
 	select {
-	case <-f.timerAdded:
-		t.Error("got value, want nothing")
+	case got := <-ch:
+		if got != next {
+			t.Errorf("got %s, want %s", got, next)
+		}
 	default:
+		t.Error("got nothing, want value")
+	}
+}
+
+func TestRunUntil(t *testing.T) {
+	c := NewSettableClock()
+	start := c.Now()
+
+	ch1 := c.After(time.Second)
+	ch3 := c.After(3 * time.Second)
+
+	var calledAt time.Time
+	var chFromCallback <-chan time.Time
+	c.AfterFunc(2*time.Second, func() {
+		calledAt = c.Now()
+		chFromCallback = c.After(500 * time.Millisecond)
+	})
+
+	end := start.Add(10 * time.Second)
+	c.RunUntil(end)
+
+	if now := c.Now(); now != end {
+		t.Errorf("got %s, want %s", now, end)
+	}
+	if want := start.Add(2 * time.Second); calledAt != want {
+		t.Errorf("callback: got %s, want %s", calledAt, want)
+	}
+
+	tests := []struct {
+		name string
+		ch   <-chan time.Time
+		want time.Time
+	}{
+		{name: "1s", ch: ch1, want: start.Add(time.Second)},
+		{name: "callback", ch: chFromCallback, want: start.Add(2500 * time.Millisecond)},
+		{name: "3s", ch: ch3, want: start.Add(3 * time.Second)},
+	}
+	for _, tt := range tests {
+		select {
+		case got := <-tt.ch:
+			if got != tt.want {
+				t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+			}
+		default:
+			t.Errorf("%s: got nothing, want value", tt.name)
+		}
 	}
+}
 
+func TestRunUntil_CallbacksInline(t *testing.T) {
+	tests := []struct {
+		name     string
+		newClock func() SettableClock
+	}{
+		{name: "settable", newClock: func() SettableClock { return NewSettableClock() }},
+		{name: "warpable", newClock: func() SettableClock { return NewTimeWarpableClock(WithBaseClock(NewSettableClock())) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.newClock()
+			start := c.Now()
+
+			// Each callback uses the clock without blocking on it, arming the next one
+			var calledAt []time.Time
+			var callback func()
+			callback = func() {
+				calledAt = append(calledAt, c.Now())
+				c.Timer(time.Hour).Stop()
+				if len(calledAt) < 3 {
+					c.AfterFunc(time.Second, callback)
+				}
+			}
+			c.AfterFunc(time.Second, callback)
+
+			// Callbacks have returned by the time AdvanceToNext and RunUntil do
+			if _, ok := c.AdvanceToNext(); !ok {
+				t.Fatal("got false, want true")
+			}
+			if len(calledAt) != 1 {
+				t.Fatalf("got %d calls, want 1", len(calledAt))
+			}
+			c.RunUntil(start.Add(time.Minute))
+
+			want := []time.Time{start.Add(time.Second), start.Add(2 * time.Second), start.Add(3 * time.Second)}
+			if !reflect.DeepEqual(calledAt, want) {
+				t.Errorf("got %v, want %v", calledAt, want)
+			}
+			if n := len(c.Timers()); n != 0 {
+				t.Errorf("got %d timers, want 0", n)
+			}
+		})
+	}
+}
+
+func TestBlockUntil(t *testing.T) {
+	c := NewSettableClock()
+
+	n := 5
 	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
 
-		c.Sleep(time.Second)
-	}()
+			c.Sleep(time.Duration(i+1) * time.Second)
+		}(i)
+	}
 
-	<-f.timerAdded
-	c.SetNow(time.Now().Add(time.Second))
+	waitForTimers(t, c, n)
+	c.Add(time.Duration(n) * time.Second)
 	wg.Wait()
+
+	waitForTimers(t, c, 0)
+}
+
+func TestBlockUntil_Canceled(t *testing.T) {
+	c := NewSettableClock()
+	c.After(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := c.BlockUntil(ctx, 2); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := c.WaitForTimer(ctx); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+// waitForTimers blocks until c has exactly n pending timers, failing the test if that takes too long
+func waitForTimers(t *testing.T, c SettableClock, n int) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := c.BlockUntil(ctx, n); err != nil {
+		t.Fatalf("waiting for %d timers: %s", n, err)
+	}
+}
+
+func TestSetNowStrict(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		t       time.Time
+		wantErr error
+	}{
+		{
+			name: "forwards",
+			t:    start.Add(time.Hour),
+		},
+		{
+			name: "same time",
+			t:    start,
+		},
+		{
+			name:    "backwards",
+			t:       start.Add(-time.Nanosecond),
+			wantErr: ErrTimeInPast,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSettableClock()
+			c.SetNow(start)
+
+			old, err := c.SetNowStrict(tt.t)
+			if err != tt.wantErr {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+			if old != start {
+				t.Errorf("got %s, want %s", old, start)
+			}
+
+			want := tt.t
+			if tt.wantErr != nil {
+				want = start
+			}
+			if now := c.Now(); now != want {
+				t.Errorf("got %s, want %s", now, want)
+			}
+		})
+	}
+}
+
+func TestAddStrict(t *testing.T) {
+	c := NewSettableClock()
+	start := c.Now()
+
+	if _, err := c.AddStrict(-time.Second); err != ErrTimeInPast {
+		t.Errorf("got %v, want %v", err, ErrTimeInPast)
+	}
+	if now := c.Now(); now != start {
+		t.Errorf("got %s, want %s", now, start)
+	}
+
+	old, err := c.AddStrict(time.Second)
+	if err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	if old != start {
+		t.Errorf("got %s, want %s", old, start)
+	}
+	if want := start.Add(time.Second); c.Now() != want {
+		t.Errorf("got %s, want %s", c.Now(), want)
+	}
 }
 
-func TestTimer(t *testing.T) {
+func TestSetNow_Backwards(t *testing.T) {
 	c := NewSettableClock()
-	f := c.(*faketime)
+	start := c.Now()
+
+	fired := c.After(time.Second)
+	pending := c.After(time.Minute)
 
+	c.Add(time.Second)
+	<-fired
+
+	// Already fired timers stay fired, pending ones keep their deadline
+	c.SetNow(start.Add(-time.Hour))
+	c.SetNow(start.Add(time.Second))
 	select {
-	case <-f.timerAdded:
-		t.Error("got value, want nothing")
+	case got := <-fired:
+		t.Errorf("got %s, want nothing", got)
+	case got := <-pending:
+		t.Errorf("got %s, want nothing", got)
 	default:
 	}
 
-	timer := c.Timer(time.Second)
+	c.SetNow(start.Add(time.Minute))
 	select {
-	case <-timer.C():
-		t.Error("got value, want nothing")
+	case <-pending:
 	default:
+		t.Error("got nothing, want value")
 	}
+}
 
-	c.SetNow(time.Now().Add(time.Second))
+func BenchmarkTimer_Stop(b *testing.B) {
+	for _, n := range []int{1e3, 1e5} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			c := NewSettableClock()
+			for i := 0; i < n; i++ {
+				c.Timer(time.Duration(i+1) * time.Second)
+			}
 
-	select {
-	case <-timer.C():
-	case <-time.After(50 * time.Millisecond):
-		t.Error("timer took too long to trigger")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Timer(time.Hour).Stop()
+			}
+		})
+	}
+}
+
+// armedInOrder is a property that timers due at the same time fire in the order they were armed, whichever clock
+// newClock makes and however advance moves it forward. Unless ordered, advance starts each callback in a goroutine of
+// its own, so they only need to all run once.
+func armedInOrder(newClock func() (c SettableClock, advance func(d time.Duration)), ordered bool) func(delays []uint8) bool {
+	return func(delays []uint8) bool {
+		c, advance := newClock()
+
+		var mu sync.Mutex
+		var fired []int
+		for i, d := range delays {
+			i := i
+			// Only a few distinct deadlines, so that most timers tie
+			c.AfterFunc(time.Duration(d%4+1)*time.Second, func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				fired = append(fired, i)
+			})
+		}
+		advance(time.Minute)
+
+		// Callbacks may still be running in their own goroutine
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			mu.Lock()
+			n := len(fired)
+			mu.Unlock()
+			if n == len(delays) {
+				break
+			}
+		}
+
+		want := make([]int, len(delays))
+		for i := range want {
+			want[i] = i
+		}
+		sort.SliceStable(want, func(i, j int) bool { return delays[want[i]]%4 < delays[want[j]]%4 })
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !ordered {
+			sort.Ints(fired)
+			sort.Ints(want)
+		}
+		return reflect.DeepEqual(fired, want) || (len(fired) == 0 && len(want) == 0)
+	}
+}
+
+func TestFakeTime_ArmingOrder(t *testing.T) {
+	queues := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "heap"},
+		{name: "timing wheel", opts: []Option{WithTimingWheel(time.Second)}},
+	}
+	advances := []struct {
+		name    string
+		advance func(c SettableClock, d time.Duration)
+		ordered bool
+	}{
+		{name: "RunUntil", advance: func(c SettableClock, d time.Duration) { c.RunUntil(c.Now().Add(d)) }, ordered: true},
+		{name: "Add", advance: func(c SettableClock, d time.Duration) { c.Add(d) }},
+		{name: "SetNow", advance: func(c SettableClock, d time.Duration) { c.SetNow(c.Now().Add(d)) }},
+	}
+	for _, q := range queues {
+		for _, a := range advances {
+			t.Run(q.name+" "+a.name, func(t *testing.T) {
+				newClock := func() (SettableClock, func(d time.Duration)) {
+					c := NewSettableClock(q.opts...)
+					return c, func(d time.Duration) { a.advance(c, d) }
+				}
+				if err := quick.Check(armedInOrder(newClock, a.ordered), nil); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+func TestNewSettableClock_StartTime(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	c := NewSettableClock(WithStartTime(start), WithTimingWheel(time.Millisecond))
+
+	if now := c.Now(); now != start {
+		t.Errorf("got %s, want %s", now, start)
+	}
+}
+
+func TestNewSettableClock_WarpableOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+		want string
+	}{
+		{name: "WithWarpSpeed", opt: WithWarpSpeed(60), want: "WithWarpSpeed does not apply to NewSettableClock"},
+		{name: "WithBaseClock", opt: WithBaseClock(NewRealClock()), want: "WithBaseClock does not apply to NewSettableClock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != tt.want {
+					t.Errorf("got %v, want %v", r, tt.want)
+				}
+			}()
+
+			NewSettableClock(WithStartTime(time.Now()), tt.opt)
+		})
+	}
+}
+
+func TestSettableClock_Close(t *testing.T) {
+	tests := []struct {
+		name     string
+		newClock func() SettableClock
+	}{
+		{name: "settable", newClock: func() SettableClock { return NewSettableClock() }},
+		{name: "settable timing wheel", newClock: func() SettableClock { return NewSettableClock(WithTimingWheel(time.Second)) }},
+		{name: "warpable", newClock: func() SettableClock { return NewTimeWarpableClock(WithBaseClock(NewSettableClock())) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.newClock()
+			start := c.Now()
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				c.Sleep(time.Hour)
+			}()
+			go func() {
+				defer wg.Done()
+				if err := c.BlockUntil(context.Background(), 100); err != ErrClockClosed {
+					t.Errorf("got %v, want %v", err, ErrClockClosed)
+				}
+			}()
+			after := c.After(time.Hour)
+			timer := c.Timer(time.Hour)
+			called := make(chan struct{})
+			c.AfterFunc(time.Hour, func() { close(called) })
+			ticker := c.Ticker(time.Minute)
+			tick := c.Tick(time.Minute)
+			ctx, cancel := WithTimeout(context.Background(), c, time.Hour)
+			defer cancel()
+			waitForTimers(t, c, 7)
+
+			if err := c.Close(); err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+			wg.Wait()
+
+			// Waiters are released with the time the clock was closed at
+			for _, ch := range []<-chan time.Time{after, timer.C(), ticker.C(), tick} {
+				select {
+				case got := <-ch:
+					if got != start {
+						t.Errorf("got %s, want %s", got, start)
+					}
+				default:
+					t.Error("got nothing, want value")
+				}
+			}
+			// But callbacks that were not yet due never run, so a far-off deadline is not exceeded
+			select {
+			case <-called:
+				t.Error("got callback run, want nothing")
+			case <-ctx.Done():
+				t.Errorf("got context done with %v, want not done", ctx.Err())
+			case <-time.After(10 * time.Millisecond):
+			}
+			if n := len(c.Timers()); n != 0 {
+				t.Errorf("got %d timers, want 0", n)
+			}
+
+			// Time is frozen
+			c.Add(time.Hour)
+			c.SetNow(start.Add(time.Hour))
+			c.RunUntil(start.Add(time.Hour))
+			if now := c.Now(); now != start {
+				t.Errorf("got %s, want %s", now, start)
+			}
+
+			// Channels armed later are released straight away, but callbacks never run
+			select {
+			case <-c.After(time.Hour):
+			default:
+				t.Error("got nothing, want value")
+			}
+			c.Sleep(time.Hour)
+			called = make(chan struct{})
+			c.AfterFunc(0, func() { close(called) })
+			c.AfterFunc(time.Hour, func() { close(called) })
+			ctx, cancel = WithTimeout(context.Background(), c, time.Hour)
+			defer cancel()
+			select {
+			case <-called:
+				t.Error("got callback run, want nothing")
+			case <-ctx.Done():
+				t.Errorf("got context done with %v, want not done", ctx.Err())
+			case <-time.After(10 * time.Millisecond):
+			}
+
+			if _, err := c.AddStrict(time.Second); err != ErrClockClosed {
+				t.Errorf("got %v, want %v", err, ErrClockClosed)
+			}
+			if _, err := c.SetNowStrict(start.Add(time.Second)); err != ErrClockClosed {
+				t.Errorf("got %v, want %v", err, ErrClockClosed)
+			}
+			if err := c.WaitForTimer(context.Background()); err != ErrClockClosed {
+				t.Errorf("got %v, want %v", err, ErrClockClosed)
+			}
+			if err := c.Close(); err != ErrClockClosed {
+				t.Errorf("got %v, want %v", err, ErrClockClosed)
+			}
+
+			// Stopping timers released by Close is harmless
+			timer.Stop()
+			ticker.Stop()
+		})
 	}
 }
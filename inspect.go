@@ -0,0 +1,146 @@
+package gotime
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimerKind is the function that armed a pending timer
+type TimerKind int
+
+const (
+	KindAfter TimerKind = iota
+	KindTimer
+	KindSleep
+	KindAfterFunc
+	KindTicker
+)
+
+func (k TimerKind) String() string {
+	switch k {
+	case KindAfter:
+		return "After"
+	case KindTimer:
+		return "Timer"
+	case KindSleep:
+		return "Sleep"
+	case KindAfterFunc:
+		return "AfterFunc"
+	case KindTicker:
+		return "Ticker"
+	}
+	return fmt.Sprintf("TimerKind(%d)", int(k))
+}
+
+// TimerInfo describes a pending timer, useful for reporting what a hung test is waiting on
+type TimerInfo struct {
+	// Deadline is when the timer will fire
+	Deadline time.Time
+	// Created is the clock's time when the timer was armed
+	Created time.Time
+	Kind    TimerKind
+	// Stack is the caller's stack when the timer was armed, innermost frame first. Empty unless the clock was created
+	// WithTimerStacks, or if it could not be captured.
+	Stack string
+}
+
+func (i TimerInfo) String() string {
+	return fmt.Sprintf("%s{deadline: %s, created: %s}", i.Kind, i.Deadline, i.Created)
+}
+
+// timerEntry is what a clock queues in its timers
+type timerEntry struct {
+	deadline time.Time
+	created  time.Time
+	kind     TimerKind
+	stack    []uintptr
+	// Counts up as timers are armed or reset, to order timers with the same deadline the way they fire
+	seq uint64
+
+	// Receives the time when the timer fires, unless f is set
+	ch chan<- time.Time
+	// Called instead of sending on ch for AfterFunc and Ticker
+	f func()
+}
+
+// newTimerEntry captures the caller's stack as well if stack is set
+func newTimerEntry(deadline, created time.Time, kind TimerKind, ch chan<- time.Time, f func(), stack bool) *timerEntry {
+	e := &timerEntry{
+		deadline: deadline,
+		created:  created,
+		kind:     kind,
+		ch:       ch,
+		f:        f,
+	}
+	if stack {
+		e.stack = callers()
+	}
+	return e
+}
+
+func (e *timerEntry) info() TimerInfo {
+	return TimerInfo{
+		Deadline: e.deadline,
+		Created:  e.created,
+		Kind:     e.kind,
+		Stack:    formatStack(e.stack),
+	}
+}
+
+// timerInfos returns the info for every entry, in the order they will fire
+func timerInfos(entries []*timerEntry) []TimerInfo {
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].deadline.Equal(entries[j].deadline) {
+			return entries[i].deadline.Before(entries[j].deadline)
+		}
+		return entries[i].seq < entries[j].seq
+	})
+
+	infos := make([]TimerInfo, 0, len(entries))
+	for _, e := range entries {
+		infos = append(infos, e.info())
+	}
+	return infos
+}
+
+const maxStackDepth = 32
+
+func callers() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	return pcs[:n]
+}
+
+// formatStack formats pcs like a goroutine trace, dropping the frames inside this package that armed the timer
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	internal := true
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+
+		if internal && !isInternalFrame(frame) {
+			internal = false
+		}
+		if !internal {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+func isInternalFrame(frame runtime.Frame) bool {
+	return strings.HasPrefix(frame.Function, "github.com/mgb/gotime.") && !strings.HasSuffix(frame.File, "_test.go")
+}
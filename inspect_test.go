@@ -0,0 +1,164 @@
+package gotime
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimers(t *testing.T) {
+	tests := []struct {
+		name  string
+		clock func(t *testing.T) SettableClock
+	}{
+		{
+			name:  "settable",
+			clock: func(t *testing.T) SettableClock { return NewSettableClock(WithTimerStacks()) },
+		},
+		{
+			name: "warpable",
+			clock: func(t *testing.T) SettableClock {
+				return NewTimeWarpableClock(WithBaseClock(NewSettableClock()), WithTimerStacks())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.clock(t)
+			c.SetNow(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+			start := c.Now()
+
+			if infos := c.Timers(); len(infos) != 0 {
+				t.Errorf("got %v, want nothing", infos)
+			}
+
+			c.After(3 * time.Second)
+			timer := c.Timer(time.Second)
+			defer timer.Stop()
+			go func() { c.Sleep(2 * time.Second) }()
+			timerFunc := c.AfterFunc(4*time.Second, func() {})
+			defer timerFunc.Stop()
+			ticker := c.Ticker(5 * time.Second)
+			defer ticker.Stop()
+
+			waitForTimers(t, c, 5)
+
+			want := []TimerKind{KindTimer, KindSleep, KindAfter, KindAfterFunc, KindTicker}
+			infos := c.Timers()
+			if len(infos) != len(want) {
+				t.Fatalf("got %v, want %d timers", infos, len(want))
+			}
+			for i, info := range infos {
+				if info.Kind != want[i] {
+					t.Errorf("%d: got %s, want %s", i, info.Kind, want[i])
+				}
+				if d := time.Duration(i+1) * time.Second; info.Deadline != start.Add(d) {
+					t.Errorf("%d: got deadline %s, want %s", i, info.Deadline, start.Add(d))
+				}
+				if info.Created != start {
+					t.Errorf("%d: got created %s, want %s", i, info.Created, start)
+				}
+				if !strings.Contains(info.Stack, "TestTimers") {
+					t.Errorf("%d: got stack %q, want it to contain the caller", i, info.Stack)
+				}
+			}
+		})
+	}
+}
+
+func TestTimers_SameDeadline(t *testing.T) {
+	tests := []struct {
+		name  string
+		clock SettableClock
+	}{
+		{name: "settable", clock: NewSettableClock()},
+		{name: "warpable", clock: NewTimeWarpableClock(WithBaseClock(NewSettableClock()))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.clock
+
+			// Timers with the same deadline are listed in the order they will fire, the order they were armed
+			var want []TimerKind
+			for i := 0; i < 50; i++ {
+				if i%2 == 0 {
+					c.After(time.Second)
+					want = append(want, KindAfter)
+				} else {
+					c.AfterFunc(time.Second, func() {})
+					want = append(want, KindAfterFunc)
+				}
+			}
+
+			infos := c.Timers()
+			if len(infos) != len(want) {
+				t.Fatalf("got %d timers, want %d", len(infos), len(want))
+			}
+			for i, info := range infos {
+				if info.Kind != want[i] {
+					t.Errorf("%d: got %s, want %s", i, info.Kind, want[i])
+				}
+				// Stacks are only captured WithTimerStacks
+				if info.Stack != "" {
+					t.Errorf("%d: got stack %q, want none", i, info.Stack)
+				}
+			}
+		})
+	}
+}
+
+func TestTimers_Reset(t *testing.T) {
+	tests := []struct {
+		name  string
+		clock SettableClock
+	}{
+		{name: "settable", clock: NewSettableClock(WithTimerStacks())},
+		{name: "warpable", clock: NewTimeWarpableClock(WithBaseClock(NewSettableClock()), WithTimerStacks())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.clock
+			start := c.Now()
+
+			timer := c.Timer(time.Second)
+			c.After(2 * time.Second)
+			ran := make(chan struct{})
+			timerFunc := c.AfterFunc(3*time.Second, func() { close(ran) })
+
+			// Reset while pending moves the timers in place, behind the one already due at their new deadline
+			c.Add(500 * time.Millisecond)
+			if !timer.Reset(1500 * time.Millisecond) {
+				t.Error("got Timer inactive, want active")
+			}
+			if !timerFunc.Reset(1500 * time.Millisecond) {
+				t.Error("got AfterFunc inactive, want active")
+			}
+
+			want := []TimerKind{KindAfter, KindTimer, KindAfterFunc}
+			infos := c.Timers()
+			if len(infos) != len(want) {
+				t.Fatalf("got %v, want %d timers", infos, len(want))
+			}
+			for i, info := range infos {
+				if info.Kind != want[i] {
+					t.Errorf("%d: got %s, want %s", i, info.Kind, want[i])
+				}
+				if info.Deadline != start.Add(2*time.Second) {
+					t.Errorf("%d: got deadline %s, want %s", i, info.Deadline, start.Add(2*time.Second))
+				}
+				if info.Created != start {
+					t.Errorf("%d: got created %s, want %s", i, info.Created, start)
+				}
+				if !strings.Contains(info.Stack, "TestTimers_Reset") {
+					t.Errorf("%d: got stack %q, want it to contain the caller", i, info.Stack)
+				}
+			}
+
+			c.Add(1500 * time.Millisecond)
+			if got := <-timer.C(); got != start.Add(2*time.Second) {
+				t.Errorf("got %s, want %s", got, start.Add(2*time.Second))
+			}
+			<-ran
+		})
+	}
+}
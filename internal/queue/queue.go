@@ -8,20 +8,35 @@ import (
 	"time"
 )
 
-// TimeQueue is a priority queue of channels order by their trigger time. Not concurrent safe.
-type TimeQueue interface {
-	Add(t time.Time, ch chan<- time.Time) func() bool
-	PopBeforeOrEqual(t time.Time) []chan<- time.Time
+// Handle identifies a value added to a TimeQueue, for removing or rescheduling it later
+type Handle uint64
+
+// TimeQueue is a priority queue of values ordered by their trigger time, then the order they were added or last
+// updated in. Not concurrent safe.
+type TimeQueue[T any] interface {
+	// Add queues v to trigger at t
+	Add(t time.Time, v T) Handle
+	// Remove takes the value for h out of the queue, reporting whether it was still queued
+	Remove(h Handle) bool
+	// Update moves the value for h to trigger at t instead, reporting whether it was still queued
+	Update(h Handle, t time.Time) bool
+	// PopBeforeOrEqual removes and returns every value triggering at or before t, earliest first
+	PopBeforeOrEqual(t time.Time) []T
+	// Peek returns the earliest trigger time
 	Peek() (time.Time, bool)
 	Len() int
+	// Values returns every queued value, in no particular order
+	Values() []T
 }
 
-// NewTimeQueue returns a new Timer
-func NewTimeQueue() TimeQueue {
-	return &timeQueue{}
+// NewTimeQueue returns a new TimeQueue, backed by a binary heap
+func NewTimeQueue[T any]() TimeQueue[T] {
+	return &timeQueue[T]{
+		handles: make(map[Handle]*item[T]),
+	}
 }
 
-func (o *timeQueue) String() string {
+func (o *timeQueue[T]) String() string {
 	if o.Len() == 0 {
 		return "timeQueue{}"
 	}
@@ -38,82 +53,115 @@ func (o *timeQueue) String() string {
 	)
 }
 
-func (o *timeQueue) Add(t time.Time, ch chan<- time.Time) func() bool {
-	id := o.counter
+func (o *timeQueue[T]) Add(t time.Time, v T) Handle {
+	h := o.counter
 	o.counter++
 
-	heap.Push(o, &item{
-		id: id,
-		t:  t,
-		ch: ch,
-	})
+	i := &item[T]{
+		handle: h,
+		seq:    uint64(h),
+		t:      t,
+		v:      v,
+	}
+	o.handles[h] = i
+	heap.Push(o, i)
+
+	return h
+}
 
-	return func() bool { return o.remove(id) }
+func (o *timeQueue[T]) Remove(h Handle) bool {
+	i, ok := o.handles[h]
+	if !ok {
+		return false
+	}
+	heap.Remove(o, i.index)
+	return true
 }
 
-func (o *timeQueue) PopBeforeOrEqual(t time.Time) []chan<- time.Time {
-	var chs []chan<- time.Time
+func (o *timeQueue[T]) Update(h Handle, t time.Time) bool {
+	i, ok := o.handles[h]
+	if !ok {
+		return false
+	}
+	// Rescheduling puts it behind everything else already due at t, as if it was added again
+	i.t = t
+	i.seq = uint64(o.counter)
+	o.counter++
+	heap.Fix(o, i.index)
+	return true
+}
+
+func (o *timeQueue[T]) PopBeforeOrEqual(t time.Time) []T {
+	var vs []T
 	for i, ok := o.Peek(); ok && !i.After(t); i, ok = o.Peek() {
-		chs = append(chs, heap.Pop(o).(*item).ch)
+		vs = append(vs, heap.Pop(o).(*item[T]).v)
 	}
-	return chs
+	return vs
 }
 
-func (o *timeQueue) Peek() (time.Time, bool) {
+func (o *timeQueue[T]) Peek() (time.Time, bool) {
 	if o.Len() == 0 {
 		return time.Time{}, false
 	}
 	return o.items[0].t, true
 }
 
-func (o *timeQueue) remove(id int) bool {
+func (o *timeQueue[T]) Values() []T {
+	vs := make([]T, 0, len(o.items))
 	for _, i := range o.items {
-		if i.id == id {
-			heap.Remove(o, i.index)
-			return true
-		}
+		vs = append(vs, i.v)
 	}
-	return false
+	return vs
 }
 
-type item struct {
-	// Used for cleanup
-	id int
+type item[T any] struct {
+	handle Handle
+	// Breaks ties between items with the same time, first in first out
+	seq uint64
 
-	t  time.Time
-	ch chan<- time.Time
+	t time.Time
+	v T
 
-	// The index of the item in the heap. It is needed by remove and is maintained by the heap.Interface methods.
+	// The index of the item in the heap. It is needed by Remove and Update and is maintained by the heap.Interface methods.
 	index int
 }
 
 // timeQueue implements heap.Interface and holds timers
-type timeQueue struct {
-	counter int
-	items   []*item
+type timeQueue[T any] struct {
+	// Source of both handles and sequence numbers
+	counter Handle
+	items   []*item[T]
+	// Every queued item by its handle, for Remove and Update
+	handles map[Handle]*item[T]
 }
 
-func (o *timeQueue) Len() int           { return len(o.items) }
-func (o *timeQueue) Less(i, j int) bool { return o.items[i].t.Before(o.items[j].t) }
-func (o *timeQueue) Swap(i, j int) {
+func (o *timeQueue[T]) Len() int { return len(o.items) }
+func (o *timeQueue[T]) Less(i, j int) bool {
+	if !o.items[i].t.Equal(o.items[j].t) {
+		return o.items[i].t.Before(o.items[j].t)
+	}
+	return o.items[i].seq < o.items[j].seq
+}
+func (o *timeQueue[T]) Swap(i, j int) {
 	o.items[i], o.items[j] = o.items[j], o.items[i]
 	o.items[i].index = i
 	o.items[j].index = j
 }
 
-func (o *timeQueue) Push(x interface{}) {
+func (o *timeQueue[T]) Push(x interface{}) {
 	n := len(o.items)
-	t := x.(*item)
+	t := x.(*item[T])
 	t.index = n
 	o.items = append(o.items, t)
 }
 
-func (o *timeQueue) Pop() interface{} {
+func (o *timeQueue[T]) Pop() interface{} {
 	old := o.items
 	n := len(old)
 	t := old[n-1]
 	old[n-1] = nil // avoid memory leak
 	t.index = -1   // for safety
 	o.items = old[0 : n-1]
+	delete(o.handles, t.handle)
 	return t
 }
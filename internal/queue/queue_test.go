@@ -1,8 +1,12 @@
 package queue
 
 import (
+	"fmt"
 	"math/rand"
+	"reflect"
+	"sort"
 	"testing"
+	"testing/quick"
 	"time"
 )
 
@@ -28,9 +32,9 @@ func TestTimeQueue_Add(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			q := NewTimeQueue()
+			q := NewTimeQueue[int]()
 			for i := 0; i < tt.count; i++ {
-				q.Add(time.Now(), nil)
+				q.Add(time.Now(), i)
 			}
 
 			l := q.Len()
@@ -132,9 +136,9 @@ func TestTimeQueue_PopBeforeOrEqual(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			q := NewTimeQueue()
+			q := NewTimeQueue[int]()
 			for _, i := range tt.times {
-				q.Add(i, nil)
+				q.Add(i, 0)
 			}
 
 			chs := q.PopBeforeOrEqual(tt.popTime)
@@ -202,9 +206,9 @@ func TestTimeQueue_Peek(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			q := NewTimeQueue()
+			q := NewTimeQueue[int]()
 			for _, t := range tt.times {
-				q.Add(t, nil)
+				q.Add(t, 0)
 			}
 
 			got, gotOk := q.Peek()
@@ -216,3 +220,229 @@ func TestTimeQueue_Peek(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeQueue_Remove(t *testing.T) {
+	q := NewTimeQueue[int]()
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var handles []Handle
+	for i := 0; i < 10; i++ {
+		handles = append(handles, q.Add(start.Add(time.Duration(i)*time.Second), i))
+	}
+
+	for _, i := range []int{0, 5, 9} {
+		if !q.Remove(handles[i]) {
+			t.Errorf("removing %d: got false, want true", i)
+		}
+		if q.Remove(handles[i]) {
+			t.Errorf("removing %d again: got true, want false", i)
+		}
+	}
+
+	got := q.PopBeforeOrEqual(start.Add(time.Hour))
+	want := []int{1, 2, 3, 4, 6, 7, 8}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Popped values can no longer be removed
+	if q.Remove(handles[1]) {
+		t.Error("removing a popped value: got true, want false")
+	}
+}
+
+func TestTimeQueue_Update(t *testing.T) {
+	q := NewTimeQueue[string]()
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	a := q.Add(start, "a")
+	q.Add(start.Add(time.Second), "b")
+	c := q.Add(start.Add(2*time.Second), "c")
+
+	if !q.Update(a, start.Add(3*time.Second)) {
+		t.Error("got false, want true")
+	}
+	if !q.Update(c, start.Add(-time.Second)) {
+		t.Error("got false, want true")
+	}
+
+	if got, _ := q.Peek(); !got.Equal(start.Add(-time.Second)) {
+		t.Errorf("got %s, want %s", got, start.Add(-time.Second))
+	}
+
+	got := q.PopBeforeOrEqual(start.Add(time.Hour))
+	want := []string{"c", "b", "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if q.Update(a, start) {
+		t.Error("updating a popped value: got true, want false")
+	}
+}
+
+func TestTimeQueue_Values(t *testing.T) {
+	q := NewTimeQueue[int]()
+	for i := 0; i < 5; i++ {
+		q.Add(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), i)
+	}
+
+	got := q.Values()
+	sort.Ints(got)
+	if want := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+var benchmarkSizes = []int{1e5, 1e6}
+
+// benchmarkQueues are the implementations compared by the benchmarks
+var benchmarkQueues = []struct {
+	name string
+	new  func() TimeQueue[int]
+}{
+	{name: "heap", new: NewTimeQueue[int]},
+	{name: "wheel", new: func() TimeQueue[int] { return NewTimingWheel[int](time.Millisecond) }},
+}
+
+// benchmark runs f for every implementation and size
+func benchmark(b *testing.B, f func(b *testing.B, newQueue func() TimeQueue[int], n int)) {
+	for _, q := range benchmarkQueues {
+		for _, n := range benchmarkSizes {
+			b.Run(fmt.Sprintf("%s/%d", q.name, n), func(b *testing.B) {
+				f(b, q.new, n)
+			})
+		}
+	}
+}
+
+var benchmarkStart = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// randomTimeout returns a time up to a minute after start, like a connection timeout
+func randomTimeout(r *rand.Rand, start time.Time) time.Time {
+	return start.Add(time.Duration(r.Int63n(int64(time.Minute))))
+}
+
+// fill returns a queue of n values with random timeouts, with their handles
+func fill(newQueue func() TimeQueue[int], n int, r *rand.Rand) (TimeQueue[int], []Handle) {
+	q := newQueue()
+	handles := make([]Handle, n)
+	for i := range handles {
+		handles[i] = q.Add(randomTimeout(r, benchmarkStart), i)
+	}
+	return q, handles
+}
+
+func BenchmarkTimeQueue_Add(b *testing.B) {
+	benchmark(b, func(b *testing.B, newQueue func() TimeQueue[int], n int) {
+		r := rand.New(rand.NewSource(1))
+		q, _ := fill(newQueue, n, r)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			q.Add(randomTimeout(r, benchmarkStart), i)
+		}
+	})
+}
+
+func BenchmarkTimeQueue_Remove(b *testing.B) {
+	benchmark(b, func(b *testing.B, newQueue func() TimeQueue[int], n int) {
+		r := rand.New(rand.NewSource(1))
+		q, handles := fill(newQueue, n, r)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			// Replace every timer cancelled, keeping the queue at n
+			j := r.Intn(n)
+			q.Remove(handles[j])
+			handles[j] = q.Add(randomTimeout(r, benchmarkStart), j)
+		}
+	})
+}
+
+func BenchmarkTimeQueue_Update(b *testing.B) {
+	benchmark(b, func(b *testing.B, newQueue func() TimeQueue[int], n int) {
+		r := rand.New(rand.NewSource(1))
+		q, handles := fill(newQueue, n, r)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			q.Update(handles[r.Intn(n)], randomTimeout(r, benchmarkStart))
+		}
+	})
+}
+
+func BenchmarkTimeQueue_PopBeforeOrEqual(b *testing.B) {
+	benchmark(b, func(b *testing.B, newQueue func() TimeQueue[int], n int) {
+		r := rand.New(rand.NewSource(1))
+
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			q, _ := fill(newQueue, n, r)
+			b.StartTimer()
+
+			// Pop the whole minute in batches of 100ms
+			for t := benchmarkStart; !t.After(benchmarkStart.Add(time.Minute)); t = t.Add(100 * time.Millisecond) {
+				q.PopBeforeOrEqual(t)
+			}
+		}
+	})
+}
+
+// Ties are popped first in first out, with updated values going behind everything else already at their new time
+func TestTimeQueue_FIFO(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	type model struct {
+		t   time.Time
+		seq int
+		v   int
+	}
+
+	property := func(offsets []uint8, updates []uint8) bool {
+		q := NewTimeQueue[int]()
+		var handles []Handle
+		var want []model
+		seq := 0
+
+		// Only a few distinct times, so that most values tie
+		for i, o := range offsets {
+			at := start.Add(time.Duration(o%4) * time.Second)
+			handles = append(handles, q.Add(at, i))
+			want = append(want, model{t: at, seq: seq, v: i})
+			seq++
+		}
+		for _, u := range updates {
+			if len(handles) == 0 {
+				break
+			}
+			i := int(u) % len(handles)
+			at := start.Add(time.Duration(u%4) * time.Second)
+			q.Update(handles[i], at)
+			want[i].t, want[i].seq = at, seq
+			seq++
+		}
+
+		sort.Slice(want, func(i, j int) bool {
+			if !want[i].t.Equal(want[j].t) {
+				return want[i].t.Before(want[j].t)
+			}
+			return want[i].seq < want[j].seq
+		})
+
+		got := q.PopBeforeOrEqual(start.Add(time.Minute))
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if got[i] != want[i].v {
+				return false
+			}
+		}
+		return true
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
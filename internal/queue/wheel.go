@@ -0,0 +1,303 @@
+package queue
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	wheelBits  = 6
+	wheelSlots = 1 << wheelBits
+	// Enough levels for every tick an int64 can count
+	wheelLevels = (63 + wheelBits - 1) / wheelBits
+)
+
+// NewTimingWheel returns a new TimeQueue backed by a hierarchical timing wheel that counts time in ticks of
+// granularity. Adding and removing are O(1), which suits large numbers of timers that are mostly cancelled before
+// they fire. Trigger times are kept exactly, so values pop in the same order as from NewTimeQueue whatever the
+// granularity, but popping is fastest when few values share a tick.
+func NewTimingWheel[T any](granularity time.Duration) TimeQueue[T] {
+	if granularity <= 0 {
+		panic("non-positive granularity for TimingWheel")
+	}
+
+	return &timingWheel[T]{
+		granularity: granularity,
+		handles:     make(map[Handle]*wheelItem[T]),
+		overdue:     NewTimeQueue[*wheelItem[T]](),
+	}
+}
+
+type wheelItem[T any] struct {
+	handle Handle
+	seq    uint64
+
+	t    time.Time
+	tick int64
+	v    T
+
+	// Where the item is: its level and slot in the wheel, and its index within the slot. A level of -1 means it is in
+	// overdue instead, under overdueHandle.
+	level, slot, index int
+	overdueHandle      Handle
+}
+
+// timingWheel places each item by its tick in the lowest level whose slots can still tell it apart from the cursor.
+// Level 0 holds the items in the same run of 64 ticks as the cursor, one slot per tick, level 1 those in the same run
+// of 64*64 ticks, one slot per 64 ticks, and so on. So everything in level 0 comes before everything in level 1, and
+// within each level, slots are in order. When the earliest items are in a higher level, the cursor moves forward to
+// their slot and they cascade down into lower levels.
+type timingWheel[T any] struct {
+	granularity time.Duration
+	// Ticks are counted from the first item added
+	origin  time.Time
+	started bool
+
+	// The current tick. Every item in the wheel is at or after it.
+	cursor   int64
+	slots    [wheelLevels][wheelSlots][]*wheelItem[T]
+	occupied [wheelLevels]uint64
+
+	// Items added before the cursor, which always come before everything in the wheel
+	overdue TimeQueue[*wheelItem[T]]
+
+	counter Handle
+	handles map[Handle]*wheelItem[T]
+}
+
+func (w *timingWheel[T]) String() string {
+	if w.Len() == 0 {
+		return "timingWheel{}"
+	}
+
+	var ts []string
+	for _, i := range w.handles {
+		ts = append(ts, fmt.Sprint(i.t))
+	}
+	sort.Strings(ts)
+
+	return fmt.Sprintf("timingWheel{len:%d, granularity: %s, timers: %s}",
+		w.Len(),
+		w.granularity,
+		strings.Join(ts, ", "),
+	)
+}
+
+// tickOf returns the tick t falls in, which is negative before the origin
+func (w *timingWheel[T]) tickOf(t time.Time) int64 {
+	d := t.Sub(w.origin)
+	if d < 0 {
+		return -1
+	}
+	return int64(d / w.granularity)
+}
+
+func (w *timingWheel[T]) Add(t time.Time, v T) Handle {
+	if !w.started {
+		w.started = true
+		w.origin = t
+	}
+
+	h := w.counter
+	w.counter++
+
+	i := &wheelItem[T]{
+		handle: h,
+		seq:    uint64(h),
+		t:      t,
+		tick:   w.tickOf(t),
+		v:      v,
+	}
+	w.handles[h] = i
+	w.place(i)
+
+	return h
+}
+
+// place puts i in the wheel, or in overdue if it is before the cursor
+func (w *timingWheel[T]) place(i *wheelItem[T]) {
+	if i.tick < w.cursor {
+		i.level = -1
+		i.overdueHandle = w.overdue.Add(i.t, i)
+		return
+	}
+
+	level := 0
+	if diff := uint64(i.tick ^ w.cursor); diff != 0 {
+		level = (bits.Len64(diff) - 1) / wheelBits
+	}
+	slot := int(i.tick>>(level*wheelBits)) & (wheelSlots - 1)
+
+	i.level, i.slot, i.index = level, slot, len(w.slots[level][slot])
+	w.slots[level][slot] = append(w.slots[level][slot], i)
+	w.occupied[level] |= 1 << slot
+}
+
+// unplace takes i back out of wherever place put it
+func (w *timingWheel[T]) unplace(i *wheelItem[T]) {
+	if i.level < 0 {
+		w.overdue.Remove(i.overdueHandle)
+		return
+	}
+
+	items := w.slots[i.level][i.slot]
+	last := len(items) - 1
+	items[i.index] = items[last]
+	items[i.index].index = i.index
+	items[last] = nil
+	w.slots[i.level][i.slot] = items[:last]
+
+	if last == 0 {
+		w.occupied[i.level] &^= 1 << i.slot
+	}
+}
+
+func (w *timingWheel[T]) Remove(h Handle) bool {
+	i, ok := w.handles[h]
+	if !ok {
+		return false
+	}
+	w.unplace(i)
+	delete(w.handles, h)
+	return true
+}
+
+func (w *timingWheel[T]) Update(h Handle, t time.Time) bool {
+	i, ok := w.handles[h]
+	if !ok {
+		return false
+	}
+	w.unplace(i)
+
+	// Rescheduling puts it behind everything else already due at t, as if it was added again
+	i.t, i.tick = t, w.tickOf(t)
+	i.seq = uint64(w.counter)
+	w.counter++
+	w.place(i)
+	return true
+}
+
+// earliestSlot returns the first occupied slot at or after the cursor, looking through the levels in order
+func (w *timingWheel[T]) earliestSlot() (level, slot int, ok bool) {
+	for level := 0; level < wheelLevels; level++ {
+		from := int(w.cursor>>(level*wheelBits)) & (wheelSlots - 1)
+		if level > 0 {
+			// The cursor's own slot in a higher level is always empty, as its items belong in a lower level
+			from++
+		}
+		if from >= wheelSlots {
+			continue
+		}
+
+		if occupied := w.occupied[level] >> from; occupied != 0 {
+			return level, from + bits.TrailingZeros64(occupied), true
+		}
+	}
+	return 0, 0, false
+}
+
+// settle cascades higher levels down until the earliest items in the wheel are in level 0, returning their slot
+func (w *timingWheel[T]) settle() (int, bool) {
+	for {
+		level, slot, ok := w.earliestSlot()
+		if !ok || level == 0 {
+			return slot, ok
+		}
+
+		// Move the cursor to the start of the slot, where its items can be told apart again
+		shift := (level + 1) * wheelBits
+		w.cursor = w.cursor>>shift<<shift | int64(slot)<<(level*wheelBits)
+
+		items := w.slots[level][slot]
+		w.slots[level][slot] = nil
+		w.occupied[level] &^= 1 << slot
+		for _, i := range items {
+			w.place(i)
+		}
+	}
+}
+
+func (w *timingWheel[T]) PopBeforeOrEqual(t time.Time) []T {
+	var vs []T
+	for _, i := range w.overdue.PopBeforeOrEqual(t) {
+		delete(w.handles, i.handle)
+		vs = append(vs, i.v)
+	}
+	if w.overdue.Len() > 0 {
+		// Still overdue items after t, so nothing in the wheel can be due
+		return vs
+	}
+
+	until := w.tickOf(t)
+	for {
+		slot, ok := w.settle()
+		if !ok {
+			return vs
+		}
+
+		tick := w.cursor&^(wheelSlots-1) | int64(slot)
+		if tick > until {
+			return vs
+		}
+		w.cursor = tick
+
+		var due []*wheelItem[T]
+		for _, i := range append([]*wheelItem[T](nil), w.slots[0][slot]...) {
+			if !i.t.After(t) {
+				due = append(due, i)
+				w.unplace(i)
+				delete(w.handles, i.handle)
+			}
+		}
+		sort.Slice(due, func(a, b int) bool { return before(due[a], due[b]) })
+		for _, i := range due {
+			vs = append(vs, i.v)
+		}
+
+		if len(w.slots[0][slot]) > 0 {
+			// The rest of the tick comes after t
+			return vs
+		}
+	}
+}
+
+func before[T any](a, b *wheelItem[T]) bool {
+	if !a.t.Equal(b.t) {
+		return a.t.Before(b.t)
+	}
+	return a.seq < b.seq
+}
+
+func (w *timingWheel[T]) Peek() (time.Time, bool) {
+	if t, ok := w.overdue.Peek(); ok {
+		return t, true
+	}
+
+	slot, ok := w.settle()
+	if !ok {
+		return time.Time{}, false
+	}
+
+	var earliest *wheelItem[T]
+	for _, i := range w.slots[0][slot] {
+		if earliest == nil || before(i, earliest) {
+			earliest = i
+		}
+	}
+	return earliest.t, true
+}
+
+func (w *timingWheel[T]) Len() int {
+	return len(w.handles)
+}
+
+func (w *timingWheel[T]) Values() []T {
+	vs := make([]T, 0, len(w.handles))
+	for _, i := range w.handles {
+		vs = append(vs, i.v)
+	}
+	return vs
+}
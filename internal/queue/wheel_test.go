@@ -0,0 +1,112 @@
+package queue
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// TestTimingWheel_MatchesHeap runs the same random operations against a timing wheel and the heap, which must agree on
+// every result
+func TestTimingWheel_MatchesHeap(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	property := func(seed int64, granularity uint16) bool {
+		r := rand.New(rand.NewSource(seed))
+		wheel := NewTimingWheel[int](time.Duration(granularity%1000+1) * time.Millisecond)
+		heap := NewTimeQueue[int]()
+
+		// Times spread from milliseconds to days either side of start, so that every level of the wheel is used, and
+		// some land on the same instant
+		randomTime := func() time.Time {
+			switch r.Intn(4) {
+			case 0:
+				return start.Add(time.Duration(r.Intn(10)) * time.Millisecond)
+			case 1:
+				return start.Add(time.Duration(r.Int63n(int64(time.Hour))))
+			case 2:
+				return start.Add(time.Duration(r.Int63n(int64(1000*time.Hour)) - int64(10*time.Hour)))
+			}
+			return start.Add(time.Duration(r.Intn(100)) * time.Second)
+		}
+
+		var handles []Handle
+		now := start
+		for op := 0; op < 500; op++ {
+			switch r.Intn(6) {
+			case 0, 1:
+				at, v := randomTime(), op
+				hw, hh := wheel.Add(at, v), heap.Add(at, v)
+				if hw != hh {
+					return false
+				}
+				handles = append(handles, hw)
+			case 2:
+				if len(handles) == 0 {
+					continue
+				}
+				h := handles[r.Intn(len(handles))]
+				if wheel.Remove(h) != heap.Remove(h) {
+					return false
+				}
+			case 3:
+				if len(handles) == 0 {
+					continue
+				}
+				h, at := handles[r.Intn(len(handles))], randomTime()
+				if wheel.Update(h, at) != heap.Update(h, at) {
+					return false
+				}
+			case 4:
+				now = now.Add(time.Duration(r.Int63n(int64(10 * time.Hour))))
+				if !reflect.DeepEqual(wheel.PopBeforeOrEqual(now), heap.PopBeforeOrEqual(now)) {
+					return false
+				}
+			case 5:
+				tw, okw := wheel.Peek()
+				th, okh := heap.Peek()
+				if okw != okh || !tw.Equal(th) {
+					return false
+				}
+			}
+
+			if wheel.Len() != heap.Len() {
+				return false
+			}
+		}
+
+		end := now.Add(10000 * time.Hour)
+		return reflect.DeepEqual(wheel.PopBeforeOrEqual(end), heap.PopBeforeOrEqual(end)) && wheel.Len() == 0
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTimingWheel_PopBeforeOrEqual(t *testing.T) {
+	start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	w := NewTimingWheel[int](time.Second)
+
+	w.Add(start.Add(1500*time.Millisecond), 0)
+	w.Add(start.Add(1200*time.Millisecond), 1)
+	w.Add(start.Add(90*24*time.Hour), 2)
+	w.Add(start.Add(-time.Hour), 3)
+
+	// Part of a tick pops only what is due within it
+	if got, want := w.PopBeforeOrEqual(start.Add(1300*time.Millisecond)), []int{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, _ := w.Peek(); !got.Equal(start.Add(1500 * time.Millisecond)) {
+		t.Errorf("got %s, want %s", got, start.Add(1500*time.Millisecond))
+	}
+
+	if got, want := w.PopBeforeOrEqual(start.Add(100*24*time.Hour)), []int{0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, ok := w.Peek(); ok {
+		t.Error("got ok, want empty")
+	}
+}
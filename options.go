@@ -0,0 +1,94 @@
+package gotime
+
+import (
+	"math"
+	"time"
+
+	"github.com/mgb/gotime/internal/queue"
+)
+
+// Option configures a clock created by NewSettableClock or NewTimeWarpableClock
+type Option func(*options)
+
+type options struct {
+	start time.Time
+	ratio float64
+	base  Clock
+	// Granularity of the timing wheel holding pending timers, or 0 for a heap
+	wheel time.Duration
+	// Capture the stack of every timer armed
+	stacks bool
+
+	// Name of an option given that only applies to NewTimeWarpableClock, for NewSettableClock to reject
+	warpableOnly string
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		ratio: 1,
+		base:  NewRealClock(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// newTimeQueue returns the queue for pending timers
+func (o options) newTimeQueue() queue.TimeQueue[*timerEntry] {
+	if o.wheel > 0 {
+		return queue.NewTimingWheel[*timerEntry](o.wheel)
+	}
+	return queue.NewTimeQueue[*timerEntry]()
+}
+
+// WithStartTime starts the clock at t instead of the base clock's current time, or the start of 1970 for a
+// SettableClock
+func WithStartTime(t time.Time) Option {
+	return func(o *options) {
+		o.start = t
+	}
+}
+
+// WithWarpSpeed starts the clock at the given warp speed instead of real time. Panics with ErrNegativeRatio if ratio is
+// not a positive number, the same as SetWarpSpeed would reject it.
+func WithWarpSpeed(ratio float64) Option {
+	if ratio <= 0 || math.IsNaN(ratio) || math.IsInf(ratio, 0) {
+		panic(ErrNegativeRatio)
+	}
+
+	return func(o *options) {
+		o.ratio = ratio
+		o.warpableOnly = "WithWarpSpeed"
+	}
+}
+
+// WithBaseClock drives the clock from c instead of real time, such as a SettableClock for unit tests
+func WithBaseClock(c Clock) Option {
+	return func(o *options) {
+		o.base = c
+		o.warpableOnly = "WithBaseClock"
+	}
+}
+
+// WithTimingWheel keeps pending timers in a hierarchical timing wheel that counts time in ticks of granularity,
+// instead of a binary heap. Timers still fire at exactly their deadline, in the same order, but arming and stopping
+// them no longer slows down as more are pending, which suits millions of short timers that are mostly stopped before
+// they fire, such as connection timeouts. Panics if granularity is not positive.
+func WithTimingWheel(granularity time.Duration) Option {
+	if granularity <= 0 {
+		panic("non-positive granularity for WithTimingWheel")
+	}
+
+	return func(o *options) {
+		o.wheel = granularity
+	}
+}
+
+// WithTimerStacks captures the caller's stack whenever a timer is armed, reported by Timers to track down what a hung
+// test is waiting on. Off by default, as capturing the stack is far slower than arming the timer.
+func WithTimerStacks() Option {
+	return func(o *options) {
+		o.stacks = true
+	}
+}
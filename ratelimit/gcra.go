@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+// Never is how long GCRA.AllowN says to wait for events that will never fit in the bucket
+const Never time.Duration = math.MaxInt64
+
+// GCRA is a leaky bucket rate limiter using the generic cell rate algorithm. Events drain from the bucket one every
+// interval, and up to burst of them may be in the bucket at once. Rather than counting tokens, it tracks the
+// theoretical arrival time of the next event, which makes every decision exact. It is safe for concurrent use.
+type GCRA struct {
+	c        gotime.Clock
+	interval time.Duration
+	burst    int
+
+	mu sync.Mutex
+	// The theoretical arrival time: when the bucket will have drained completely
+	tat time.Time
+}
+
+// NewGCRA returns an empty leaky bucket that drains one event every interval on c and holds up to burst events
+func NewGCRA(c gotime.Clock, interval time.Duration, burst int) *GCRA {
+	if interval <= 0 {
+		panic("non-positive interval for GCRA")
+	}
+
+	return &GCRA{
+		c:        c,
+		interval: interval,
+		burst:    burst,
+	}
+}
+
+// Allow is AllowN for a single event
+func (g *GCRA) Allow() (bool, time.Duration) {
+	return g.AllowN(1)
+}
+
+// AllowN reports whether n events fit in the bucket now, adding them if they do. Otherwise it returns how long until
+// they would fit, which is Never if n is negative or more than burst.
+func (g *GCRA) AllowN(n int) (bool, time.Duration) {
+	if n < 0 || n > g.burst {
+		return false, Never
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.c.Now()
+	tat, allowAt := g.next(now, n)
+	if allowAt.After(now) {
+		return false, allowAt.Sub(now)
+	}
+	g.tat = tat
+	return true, 0
+}
+
+// next returns the theoretical arrival time after n more events, and when they would fit in the bucket.
+// Must only be used when holding the lock.
+func (g *GCRA) next(now time.Time, n int) (tat, allowAt time.Time) {
+	tat = g.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	tat = tat.Add(time.Duration(n) * g.interval)
+
+	return tat, tat.Add(-time.Duration(g.burst) * g.interval)
+}
+
+// Wait is WaitN for a single event
+func (g *GCRA) Wait(ctx context.Context) error {
+	return g.WaitN(ctx, 1)
+}
+
+// WaitN queues n events in the bucket and waits on the clock until they fit, so that waiters are let through evenly
+// one interval apart. It returns ErrExceedsBurst if n is more than the bucket ever holds, ErrNegativeEvents if n is
+// negative, or the context's error if ctx is done first, in which case the events are taken back out.
+func (g *GCRA) WaitN(ctx context.Context, n int) error {
+	if n < 0 {
+		return fmt.Errorf("%w: %d events", ErrNegativeEvents, n)
+	}
+	if n > g.burst {
+		return fmt.Errorf("%w: %d events with a burst of %d", ErrExceedsBurst, n, g.burst)
+	}
+
+	g.mu.Lock()
+	now := g.c.Now()
+	tat, allowAt := g.next(now, n)
+	g.tat = tat
+	g.mu.Unlock()
+
+	return wait(ctx, g.c, &Reservation{
+		c:      g.c,
+		ok:     true,
+		at:     allowAt,
+		cancel: func() { g.restore(n, tat) },
+	})
+}
+
+// restore takes back n events queued with the theoretical arrival time tat, as long as none were queued after them
+func (g *GCRA) restore(n int, tat time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.tat.Equal(tat) {
+		g.tat = tat.Add(-time.Duration(n) * g.interval)
+	}
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+func TestGCRA_Allow(t *testing.T) {
+	c := gotime.NewSettableClock()
+	g := NewGCRA(c, time.Second, 3)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := g.Allow(); !ok {
+			t.Fatalf("got denied on event %d, want allowed by the burst", i)
+		}
+	}
+
+	ok, retryAfter := g.Allow()
+	if ok || retryAfter != time.Second {
+		t.Fatalf("got %t retrying after %s, want denied retrying after %s", ok, retryAfter, time.Second)
+	}
+
+	c.Add(500 * time.Millisecond)
+	if ok, retryAfter := g.Allow(); ok || retryAfter != 500*time.Millisecond {
+		t.Fatalf("got %t retrying after %s, want denied retrying after %s", ok, retryAfter, 500*time.Millisecond)
+	}
+
+	c.Add(500 * time.Millisecond)
+	if ok, _ := g.Allow(); !ok {
+		t.Fatal("got denied once an event drained, want allowed")
+	}
+
+	// Never fits, however long the caller waits
+	for _, n := range []int{4, -1} {
+		if ok, retryAfter := g.AllowN(n); ok || retryAfter != Never {
+			t.Errorf("%d: got %t retrying after %s, want denied retrying after %s", n, ok, retryAfter, Never)
+		}
+	}
+	c.Add(time.Hour)
+	if ok, retryAfter := g.AllowN(4); ok || retryAfter != Never {
+		t.Errorf("got %t retrying after %s, want denied retrying after %s", ok, retryAfter, Never)
+	}
+	if err := g.WaitN(context.Background(), -1); !errors.Is(err, ErrNegativeEvents) {
+		t.Errorf("got %v, want %s", err, ErrNegativeEvents)
+	}
+}
+
+func TestGCRA_Wait(t *testing.T) {
+	c := gotime.NewSettableClock()
+	g := NewGCRA(c, time.Second, 1)
+
+	if err := g.Wait(context.Background()); err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	// Queued waiters are let through an interval apart
+	first := waitInBackground(g.Wait, context.Background())
+	waitForTimers(t, c, 1)
+	second := waitInBackground(g.Wait, context.Background())
+	waitForTimers(t, c, 2)
+
+	c.Add(time.Second)
+	assertReleased(t, first, nil)
+	assertBlocked(t, second)
+
+	c.Add(time.Second)
+	assertReleased(t, second, nil)
+}
+
+func TestGCRA_WaitCancel(t *testing.T) {
+	c := gotime.NewSettableClock()
+	g := NewGCRA(c, time.Second, 1)
+	g.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := waitInBackground(g.Wait, ctx)
+	waitForTimers(t, c, 1)
+
+	cancel()
+	assertReleased(t, done, context.Canceled)
+
+	// The cancelled wait was taken back out of the bucket
+	c.Add(time.Second)
+	if ok, _ := g.Allow(); !ok {
+		t.Error("got denied, want allowed")
+	}
+
+	if err := g.WaitN(context.Background(), 2); !errors.Is(err, ErrExceedsBurst) {
+		t.Errorf("got %v, want %s", err, ErrExceedsBurst)
+	}
+}
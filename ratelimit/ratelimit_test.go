@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+func waitForTimers(t *testing.T, c gotime.SettableClock, n int) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := c.BlockUntil(ctx, n); err != nil {
+		t.Fatalf("waiting for %d timers: %s", n, err)
+	}
+}
+
+// waitInBackground calls wait in its own goroutine, returning a channel with its result
+func waitInBackground(wait func(ctx context.Context) error, ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- wait(ctx) }()
+	return done
+}
+
+func assertBlocked(t *testing.T, done <-chan error) {
+	t.Helper()
+
+	select {
+	case err := <-done:
+		t.Fatalf("got %v, want still waiting", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func assertReleased(t *testing.T, done <-chan error, exp error) {
+	t.Helper()
+
+	select {
+	case err := <-done:
+		if err != exp {
+			t.Errorf("got %v, want %v", err, exp)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("still waiting")
+	}
+}
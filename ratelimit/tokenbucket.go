@@ -0,0 +1,220 @@
+// Package ratelimit limits how often events happen, measuring time on a gotime.Clock
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+var (
+	// ErrExceedsBurst is returned when waiting for more events at once than a limiter could ever allow
+	ErrExceedsBurst = errors.New("exceeds burst")
+
+	// ErrNegativeEvents is returned when waiting for a negative number of events
+	ErrNegativeEvents = errors.New("negative number of events")
+)
+
+// TokenBucket is a rate limiter that holds up to burst tokens, refilling one every interval. Each event takes a token.
+// It is safe for concurrent use.
+type TokenBucket struct {
+	c        gotime.Clock
+	interval time.Duration
+	burst    int
+
+	mu sync.Mutex
+	// Negative while tokens are owed to reservations
+	tokens int
+	// When tokens was last topped up. The next token arrives an interval after it.
+	last time.Time
+}
+
+// NewTokenBucket returns a full bucket of burst tokens that refills one token every interval on c
+func NewTokenBucket(c gotime.Clock, interval time.Duration, burst int) *TokenBucket {
+	if interval <= 0 {
+		panic("non-positive interval for TokenBucket")
+	}
+
+	return &TokenBucket{
+		c:        c,
+		interval: interval,
+		burst:    burst,
+		tokens:   burst,
+		last:     c.Now(),
+	}
+}
+
+// Tokens returns how many tokens are available now, which is negative while tokens are owed to reservations
+func (b *TokenBucket) Tokens() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(b.c.Now())
+	return b.tokens
+}
+
+// refill tops up every token that has arrived by now. Must only be used when holding the lock.
+func (b *TokenBucket) refill(now time.Time) {
+	if !now.After(b.last) {
+		return
+	}
+
+	arrived := int(now.Sub(b.last) / b.interval)
+	if b.tokens+arrived >= b.burst {
+		// A full bucket doesn't bank time towards the next token
+		b.tokens = b.burst
+		b.last = now
+		return
+	}
+	b.tokens += arrived
+	b.last = b.last.Add(time.Duration(arrived) * b.interval)
+}
+
+// Allow is AllowN for a single event
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN takes n tokens if they are all available now, reporting whether they were. It never allows a negative n.
+func (b *TokenBucket) AllowN(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(b.c.Now())
+	if n < 0 || b.tokens < n {
+		return false
+	}
+	b.tokens -= n
+	return true
+}
+
+// Reserve is ReserveN for a single event
+func (b *TokenBucket) Reserve() *Reservation {
+	return b.ReserveN(1)
+}
+
+// ReserveN takes n tokens now, going into debt if needed, and returns when the event may happen. The reservation is
+// not OK, and takes nothing, if n is negative or more than the bucket ever holds.
+func (b *TokenBucket) ReserveN(n int) *Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.c.Now()
+	if n < 0 || n > b.burst {
+		return &Reservation{c: b.c}
+	}
+
+	b.refill(now)
+	b.tokens -= n
+
+	at := now
+	if b.tokens < 0 {
+		at = b.last.Add(time.Duration(-b.tokens) * b.interval)
+	}
+
+	return &Reservation{
+		c:      b.c,
+		ok:     true,
+		at:     at,
+		cancel: func() { b.restore(n, at) },
+	}
+}
+
+// restore gives n tokens back for a reservation at at that was cancelled
+func (b *TokenBucket) restore(n int, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.c.Now()
+	if !at.After(now) {
+		// Already used
+		return
+	}
+
+	b.refill(now)
+	b.tokens += n
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// Wait is WaitN for a single event
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return b.WaitN(ctx, 1)
+}
+
+// WaitN waits on the clock until n tokens can be taken, returning ErrExceedsBurst if n is more than the bucket ever
+// holds, ErrNegativeEvents if n is negative, or the context's error if ctx is done first, in which case the tokens are
+// given back.
+func (b *TokenBucket) WaitN(ctx context.Context, n int) error {
+	if n < 0 {
+		return fmt.Errorf("%w: %d tokens", ErrNegativeEvents, n)
+	}
+	if n > b.burst {
+		return fmt.Errorf("%w: %d tokens with a burst of %d", ErrExceedsBurst, n, b.burst)
+	}
+	return wait(ctx, b.c, b.ReserveN(n))
+}
+
+// wait sleeps on c until r may go ahead, cancelling it if ctx is done first
+func wait(ctx context.Context, c gotime.Clock, r *Reservation) error {
+	if err := ctx.Err(); err != nil {
+		r.Cancel()
+		return err
+	}
+
+	d := r.Delay()
+	if d <= 0 {
+		return nil
+	}
+
+	t := c.Timer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Reservation is permission for events to happen at a set time
+type Reservation struct {
+	c      gotime.Clock
+	ok     bool
+	at     time.Time
+	cancel func()
+	once   sync.Once
+}
+
+// OK reports whether the limiter can ever allow the events. Nothing else about a reservation that isn't OK is useful.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Time returns when the events may happen
+func (r *Reservation) Time() time.Time {
+	return r.at
+}
+
+// Delay returns how long until the events may happen, which is 0 once they can
+func (r *Reservation) Delay() time.Duration {
+	if d := r.c.Until(r.at); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel gives back what was reserved, if it is still in the future, so that other events can use it
+func (r *Reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+	r.once.Do(r.cancel)
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+func TestTokenBucket_Allow(t *testing.T) {
+	c := gotime.NewSettableClock()
+	b := NewTokenBucket(c, time.Second, 3)
+
+	for i := 0; i < 3; i++ {
+		if !b.Allow() {
+			t.Fatalf("got denied on event %d, want allowed by the burst", i)
+		}
+	}
+	if b.Allow() {
+		t.Fatal("got allowed with an empty bucket, want denied")
+	}
+
+	c.Add(time.Second - time.Nanosecond)
+	if b.Allow() {
+		t.Fatal("got allowed before a token arrived, want denied")
+	}
+	c.Add(time.Nanosecond)
+	if !b.Allow() {
+		t.Fatal("got denied once a token arrived, want allowed")
+	}
+
+	// A full bucket stops filling
+	c.Add(time.Hour)
+	if got := b.Tokens(); got != 3 {
+		t.Errorf("got %d tokens, want 3", got)
+	}
+	if b.AllowN(4) {
+		t.Error("got allowed more than the burst, want denied")
+	}
+	if !b.AllowN(3) {
+		t.Error("got denied the whole burst, want allowed")
+	}
+}
+
+func TestTokenBucket_Negative(t *testing.T) {
+	c := gotime.NewSettableClock()
+	b := NewTokenBucket(c, time.Second, 3)
+
+	// Negative events would otherwise fill the bucket past its burst
+	if b.AllowN(-5) {
+		t.Error("got allowed, want denied")
+	}
+	if r := b.ReserveN(-5); r.OK() {
+		t.Error("got reservation OK, want not OK")
+	}
+	if err := b.WaitN(context.Background(), -5); !errors.Is(err, ErrNegativeEvents) {
+		t.Errorf("got %v, want %s", err, ErrNegativeEvents)
+	}
+	if tokens := b.Tokens(); tokens != 3 {
+		t.Errorf("got %d tokens, want 3", tokens)
+	}
+}
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	c := gotime.NewSettableClock()
+	b := NewTokenBucket(c, time.Second, 2)
+
+	tests := []time.Duration{0, 0, time.Second, 2 * time.Second}
+	var reservations []*Reservation
+	for _, exp := range tests {
+		r := b.Reserve()
+		if !r.OK() {
+			t.Fatal("got not OK, want OK")
+		}
+		if got := r.Delay(); got != exp {
+			t.Errorf("got %s, want %s", got, exp)
+		}
+		reservations = append(reservations, r)
+	}
+
+	if got := b.Tokens(); got != -2 {
+		t.Errorf("got %d tokens, want -2", got)
+	}
+
+	// Cancelling gives the tokens back for the next reservation
+	reservations[3].Cancel()
+	reservations[3].Cancel()
+	if got := b.Reserve().Delay(); got != 2*time.Second {
+		t.Errorf("got %s, want %s", got, 2*time.Second)
+	}
+
+	if b.ReserveN(3).OK() {
+		t.Error("got OK for more than the burst, want not OK")
+	}
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	c := gotime.NewSettableClock()
+	b := NewTokenBucket(c, time.Second, 1)
+
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	done := waitInBackground(b.Wait, context.Background())
+	waitForTimers(t, c, 1)
+
+	c.Add(time.Second - time.Nanosecond)
+	assertBlocked(t, done)
+
+	c.Add(time.Nanosecond)
+	assertReleased(t, done, nil)
+}
+
+func TestTokenBucket_WaitCancel(t *testing.T) {
+	c := gotime.NewSettableClock()
+	b := NewTokenBucket(c, time.Second, 1)
+	b.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := waitInBackground(b.Wait, ctx)
+	waitForTimers(t, c, 1)
+
+	cancel()
+	assertReleased(t, done, context.Canceled)
+
+	// The cancelled wait gave its token back
+	if got := b.Tokens(); got != 0 {
+		t.Errorf("got %d tokens, want 0", got)
+	}
+
+	if err := b.WaitN(context.Background(), 2); !errors.Is(err, ErrExceedsBurst) {
+		t.Errorf("got %v, want %s", err, ErrExceedsBurst)
+	}
+}
+
+func TestTokenBucket_Warped(t *testing.T) {
+	base := gotime.NewSettableClock()
+	c := gotime.NewTimeWarpableClock(gotime.WithBaseClock(base), gotime.WithWarpSpeed(60))
+	b := NewTokenBucket(c, time.Minute, 1)
+	b.Allow()
+
+	done := waitInBackground(b.Wait, context.Background())
+	waitForTimers(t, base, 1)
+
+	// A minute of refill takes a second at 60x
+	base.Add(time.Second)
+	assertReleased(t, done, nil)
+}
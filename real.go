@@ -15,6 +15,12 @@ func (realtime) After(d time.Duration) <-chan time.Time {
 	return time.After(d)
 }
 
+func (realtime) AfterFunc(d time.Duration, f func()) Timer {
+	return &timerWrapper{
+		t: time.AfterFunc(d, f),
+	}
+}
+
 func (realtime) Now() time.Time {
 	return time.Now()
 }
@@ -27,12 +33,29 @@ func (realtime) Sleep(d time.Duration) {
 	time.Sleep(d)
 }
 
+func (realtime) Tick(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return time.NewTicker(d).C
+}
+
+func (realtime) Ticker(d time.Duration) Ticker {
+	return &tickerWrapper{
+		t: time.NewTicker(d),
+	}
+}
+
 func (realtime) Timer(d time.Duration) Timer {
 	return &timerWrapper{
 		t: time.NewTimer(d),
 	}
 }
 
+func (realtime) Until(t time.Time) time.Duration {
+	return time.Until(t)
+}
+
 type timerWrapper struct {
 	t *time.Timer
 }
@@ -40,3 +63,11 @@ type timerWrapper struct {
 func (t *timerWrapper) C() <-chan time.Time        { return t.t.C }
 func (t *timerWrapper) Reset(d time.Duration) bool { return t.t.Reset(d) }
 func (t *timerWrapper) Stop() bool                 { return t.t.Stop() }
+
+type tickerWrapper struct {
+	t *time.Ticker
+}
+
+func (t *tickerWrapper) C() <-chan time.Time   { return t.t.C }
+func (t *tickerWrapper) Reset(d time.Duration) { t.t.Reset(d) }
+func (t *tickerWrapper) Stop()                 { t.t.Stop() }
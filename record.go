@@ -0,0 +1,316 @@
+package gotime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Operations recorded by RecordingClock
+const (
+	OpNow       = "Now"
+	OpSince     = "Since"
+	OpUntil     = "Until"
+	OpAfter     = "After"
+	OpAfterFunc = "AfterFunc"
+	OpSleep     = "Sleep"
+	OpTick      = "Tick"
+	OpTicker    = "Ticker"
+	OpTimer     = "Timer"
+	// OpStop and OpReset are calls on the Timer or Ticker created by the event in Ref
+	OpStop  = "Stop"
+	OpReset = "Reset"
+	// OpFire is a value delivered by the AfterFunc, Tick or Ticker created by the event in Ref. Values from After and
+	// Timer go straight from the wrapped clock to the caller, so are not recorded.
+	OpFire = "Fire"
+)
+
+// Event is a single call recorded by RecordingClock, written as one line of JSON
+type Event struct {
+	Seq       int    `json:"seq"`
+	Goroutine int64  `json:"goroutine"`
+	Op        string `json:"op"`
+	// Ref is the Seq of the event that created the timer or ticker for OpStop, OpReset and OpFire
+	Ref int `json:"ref,omitempty"`
+	// Gen is how many times the timer was stopped or reset before it fired, for OpFire
+	Gen int `json:"gen,omitempty"`
+	// At is the wrapped clock's time when the event was recorded
+	At time.Time `json:"at"`
+
+	// Arguments
+	Duration time.Duration `json:"duration,omitempty"`
+	Time     *time.Time    `json:"time,omitempty"`
+
+	// Results
+	Now    *time.Time     `json:"now,omitempty"`
+	Result *time.Duration `json:"result,omitempty"`
+	Active *bool          `json:"active,omitempty"`
+}
+
+func (e Event) String() string {
+	s := fmt.Sprintf("#%d %s", e.Seq, e.Op)
+	if e.Ref != 0 {
+		s += fmt.Sprintf(" of #%d", e.Ref)
+	}
+	if e.Duration != 0 {
+		s += fmt.Sprintf("(%s)", e.Duration)
+	}
+	if e.Time != nil {
+		s += fmt.Sprintf("(%s)", e.Time)
+	}
+	return s
+}
+
+// RecordingClock is a Clock that logs every call made to the Clock it wraps, along with the results and every value
+// its timers deliver, so that they can be played back with a ReplayClock
+type RecordingClock struct {
+	c Clock
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	seq int
+	err error
+}
+
+// NewRecordingClock returns a Clock that passes every call through to c, writing each one to w as JSON lines
+func NewRecordingClock(c Clock, w io.Writer) *RecordingClock {
+	return &RecordingClock{
+		c:   c,
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (r *RecordingClock) String() string {
+	return fmt.Sprintf("recording{clock: %s}", r.c)
+}
+
+// Err returns the first error writing events, after which nothing more is written
+func (r *RecordingClock) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+// record writes e, returning its sequence number
+func (r *RecordingClock) record(e Event) int {
+	e.Goroutine = goroutineID()
+	if e.At.IsZero() {
+		e.At = r.c.Now()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	e.Seq = r.seq
+	if r.err == nil {
+		r.err = r.enc.Encode(e)
+	}
+	return e.Seq
+}
+
+func (r *RecordingClock) After(d time.Duration) <-chan time.Time {
+	r.record(Event{Op: OpAfter, Duration: d})
+	return r.c.After(d)
+}
+
+func (r *RecordingClock) AfterFunc(d time.Duration, f func()) Timer {
+	return r.newTimer(OpAfterFunc, d, f)
+}
+
+func (r *RecordingClock) Now() time.Time {
+	now := r.c.Now()
+	r.record(Event{Op: OpNow, At: now, Now: &now})
+	return now
+}
+
+func (r *RecordingClock) Since(t time.Time) time.Duration {
+	d := r.c.Since(t)
+	r.record(Event{Op: OpSince, Time: &t, Result: &d})
+	return d
+}
+
+func (r *RecordingClock) Until(t time.Time) time.Duration {
+	d := r.c.Until(t)
+	r.record(Event{Op: OpUntil, Time: &t, Result: &d})
+	return d
+}
+
+func (r *RecordingClock) Sleep(d time.Duration) {
+	r.record(Event{Op: OpSleep, Duration: d})
+	r.c.Sleep(d)
+}
+
+func (r *RecordingClock) Tick(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return r.newTicker(OpTick, d).c
+}
+
+func (r *RecordingClock) Ticker(d time.Duration) Ticker {
+	return r.newTicker(OpTicker, d)
+}
+
+func (r *RecordingClock) Timer(d time.Duration) Timer {
+	t := &recordingTimer{r: r}
+	t.ref = r.record(Event{Op: OpTimer, Duration: d})
+	t.timer = r.c.Timer(d)
+
+	return t
+}
+
+func (r *RecordingClock) newTimer(op string, d time.Duration, f func()) *recordingTimer {
+	t := &recordingTimer{
+		r: r,
+		f: f,
+	}
+	t.ref = r.record(Event{Op: op, Duration: d})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.arm(d)
+
+	return t
+}
+
+// recordingTimer records every call on a Timer of the wrapped clock. For AfterFunc, it fires through a callback of its
+// own so that every call can be recorded too.
+type recordingTimer struct {
+	r   *RecordingClock
+	ref int
+	// nil unless AfterFunc
+	f func()
+
+	mu sync.Mutex
+	// Incremented on every Stop and Reset so that callbacks already in flight are dropped
+	gen   int
+	timer Timer
+}
+
+// arm must only be used when holding the lock
+func (t *recordingTimer) arm(d time.Duration) {
+	gen := t.gen
+	t.timer = t.r.c.AfterFunc(d, func() { t.fire(gen) })
+}
+
+func (t *recordingTimer) fire(gen int) {
+	now := t.r.c.Now()
+	t.r.record(Event{Op: OpFire, Ref: t.ref, Gen: gen, Now: &now})
+
+	t.f()
+}
+
+func (t *recordingTimer) C() <-chan time.Time {
+	return t.timer.C()
+}
+
+func (t *recordingTimer) Reset(d time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// The time the timer was rearmed at, from which ReplayClock works out when it fires
+	at := t.r.c.Now()
+
+	var active bool
+	if t.f == nil {
+		active = t.timer.Reset(d)
+	} else {
+		t.gen++
+		active = t.timer.Stop()
+		t.arm(d)
+	}
+	t.r.record(Event{Op: OpReset, Ref: t.ref, At: at, Duration: d, Active: &active})
+
+	return active
+}
+
+func (t *recordingTimer) Stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	active := t.timer.Stop()
+	if t.f != nil {
+		t.gen++
+	}
+	t.r.record(Event{Op: OpStop, Ref: t.ref, Active: &active})
+
+	return active
+}
+
+func (r *RecordingClock) newTicker(op string, d time.Duration) *recordingTicker {
+	t := &recordingTicker{
+		r:      r,
+		c:      make(chan time.Time, 1),
+		stopCh: make(chan struct{}),
+	}
+	t.ref = r.record(Event{Op: op, Duration: d})
+	t.ticker = r.c.Ticker(d)
+
+	go t.forward()
+
+	return t
+}
+
+// recordingTicker forwards every tick of the wrapped clock's ticker, recording it on the way
+type recordingTicker struct {
+	r      *RecordingClock
+	ref    int
+	c      chan time.Time
+	ticker Ticker
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func (t *recordingTicker) forward() {
+	for {
+		select {
+		case now := <-t.ticker.C():
+			t.r.record(Event{Op: OpFire, Ref: t.ref, Now: &now})
+
+			// Drop ticks for slow receivers, as time.Ticker does
+			select {
+			case t.c <- now:
+			default:
+			}
+		case <-t.stopCh:
+			return
+		}
+	}
+}
+
+func (t *recordingTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *recordingTicker) Reset(d time.Duration) {
+	t.ticker.Reset(d)
+	t.r.record(Event{Op: OpReset, Ref: t.ref, Duration: d})
+}
+
+func (t *recordingTicker) Stop() {
+	t.ticker.Stop()
+	t.stopOnce.Do(func() { close(t.stopCh) })
+	t.r.record(Event{Op: OpStop, Ref: t.ref})
+}
+
+// goroutineID parses the current goroutine's ID out of its stack trace, which starts with "goroutine 123 [running]:"
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,119 @@
+package gotime
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recordedWork exercises a clock the way a component under test might, returning everything it observed
+func recordedWork(c Clock) []string {
+	var seen []string
+
+	start := c.Now()
+	seen = append(seen, start.String())
+
+	t := c.Timer(time.Second)
+	seen = append(seen, (<-t.C()).String())
+	seen = append(seen, c.Since(start).String())
+
+	if active := t.Reset(2 * time.Second); active {
+		seen = append(seen, "reset active")
+	}
+	seen = append(seen, (<-c.After(time.Second)).String())
+
+	if active := t.Stop(); !active {
+		seen = append(seen, "stop inactive")
+	}
+	seen = append(seen, c.Until(start.Add(time.Hour)).String())
+
+	return seen
+}
+
+func TestRecordReplay(t *testing.T) {
+	fake := NewSettableClock()
+	var buf bytes.Buffer
+	r := NewRecordingClock(fake, &buf)
+
+	done := make(chan []string)
+	go func() { done <- recordedWork(r) }()
+
+	waitForTimers(t, fake, 1)
+	fake.Add(time.Second)
+	// The reset timer and the After
+	waitForTimers(t, fake, 2)
+	fake.Add(time.Second)
+	recorded := <-done
+
+	if err := r.Err(); err != nil {
+		t.Fatalf("recording: %s", err)
+	}
+	t.Logf("recording:\n%s", buf.String())
+
+	replay, err := NewReplayClock(&buf)
+	if err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	replayed := recordedWork(replay)
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("got %q, want %q", replayed, recorded)
+	}
+	if err := replay.Verify(); err != nil {
+		t.Errorf("got %s, want nil", err)
+	}
+}
+
+func TestReplayDiverged(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecordingClock(NewSettableClock(), &buf)
+	r.Now()
+	r.Since(time.Time{})
+
+	replay, err := NewReplayClock(&buf)
+	if err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+	replay.Now()
+
+	if err := replay.Verify(); !errors.Is(err, ErrReplayDiverged) {
+		t.Errorf("got %v, want %s", err, ErrReplayDiverged)
+	}
+
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrReplayDiverged) {
+			t.Errorf("got %v, want %s", err, ErrReplayDiverged)
+		}
+	}()
+	replay.Sleep(time.Second)
+}
+
+func TestRecordingClock_Delegates(t *testing.T) {
+	fake := NewSettableClock()
+	r := NewRecordingClock(fake, &bytes.Buffer{})
+
+	after := r.After(time.Second)
+	timer := r.Timer(time.Second)
+
+	// The wrapped clock's own timers, so it reports them as such
+	var kinds []TimerKind
+	for _, info := range fake.Timers() {
+		kinds = append(kinds, info.Kind)
+	}
+	if want := []TimerKind{KindAfter, KindTimer}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("got %v, want %v", kinds, want)
+	}
+
+	// Delivered as soon as Add returns, as the wrapped clock does
+	fake.Add(time.Second)
+	for _, ch := range []<-chan time.Time{after, timer.C()} {
+		select {
+		case <-ch:
+		default:
+			t.Error("got nothing, want value")
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package gotime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ReplayClock is a Clock that plays back the results written by a RecordingClock. Calls must be made in the same
+// order as they were recorded, with the same arguments, or it panics with ErrReplayDiverged. Timers deliver their
+// recorded values as soon as they are armed, as only the values and not the waiting are replayed. After and Timer
+// deliver their deadline, as long as they fired in the recording.
+type ReplayClock struct {
+	mu    sync.Mutex
+	calls []Event
+	next  int
+	// Recorded values, keyed by the Seq of the event that created the timer or ticker
+	fires map[int][]Event
+}
+
+// NewReplayClock reads a recording written by RecordingClock
+func NewReplayClock(r io.Reader) (*ReplayClock, error) {
+	c := &ReplayClock{
+		fires: make(map[int][]Event),
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		var e Event
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if e.Op == OpFire {
+			c.fires[e.Ref] = append(c.fires[e.Ref], e)
+			continue
+		}
+		c.calls = append(c.calls, e)
+	}
+	for _, e := range deadlineFires(c.calls) {
+		c.fires[e.Ref] = append(c.fires[e.Ref], e)
+	}
+
+	return c, nil
+}
+
+// deadlineFires works out the values delivered by After and Timer, which RecordingClock cannot see: each one's
+// deadline, as long as it fired. A timer fired unless the Stop or Reset that followed found it still active, or its
+// deadline is after everything else recorded.
+func deadlineFires(calls []Event) []Event {
+	var end time.Time
+	for _, e := range calls {
+		if e.At.After(end) {
+			end = e.At
+		}
+	}
+
+	type armed struct {
+		gen      int
+		deadline time.Time
+		pending  bool
+	}
+	timers := make(map[int]*armed)
+
+	var fires []Event
+	fire := func(ref int, a *armed) {
+		now := a.deadline
+		fires = append(fires, Event{Op: OpFire, Ref: ref, Gen: a.gen, Now: &now})
+	}
+
+	for _, e := range calls {
+		switch e.Op {
+		case OpAfter, OpTimer:
+			timers[e.Seq] = &armed{deadline: e.At.Add(e.Duration), pending: true}
+		case OpStop, OpReset:
+			// Only After and Timer are tracked, not AfterFunc and tickers
+			a, ok := timers[e.Ref]
+			if !ok {
+				continue
+			}
+			if a.pending && e.Active != nil && !*e.Active {
+				fire(e.Ref, a)
+			}
+			a.gen++
+			a.pending = e.Op == OpReset
+			a.deadline = e.At.Add(e.Duration)
+		}
+	}
+	for ref, a := range timers {
+		if a.pending && !a.deadline.After(end) {
+			fire(ref, a)
+		}
+	}
+
+	return fires
+}
+
+func (c *ReplayClock) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return fmt.Sprintf("replay{call: %d, calls: %d}", c.next, len(c.calls))
+}
+
+// Verify returns ErrReplayDiverged if any recorded calls have not been replayed yet
+func (c *ReplayClock) Verify() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next < len(c.calls) {
+		return fmt.Errorf("%w: %d calls were never made, starting with %s", ErrReplayDiverged, len(c.calls)-c.next, c.calls[c.next])
+	}
+	return nil
+}
+
+// expect returns the next recorded call, panicking if it doesn't match want's operation and arguments
+func (c *ReplayClock) expect(want Event) Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next >= len(c.calls) {
+		panic(fmt.Errorf("%w: got %s after the recording ended", ErrReplayDiverged, want))
+	}
+
+	got := c.calls[c.next]
+	if got.Op != want.Op || got.Ref != want.Ref || got.Duration != want.Duration || !sameTime(got.Time, want.Time) {
+		panic(fmt.Errorf("%w: got %s, recorded %s", ErrReplayDiverged, want, got))
+	}
+	c.next++
+
+	return got
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// fire returns the value recorded for the timer created by ref after gen stops or resets
+func (c *ReplayClock) fire(ref, gen int) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, e := range c.fires[ref] {
+		if e.Gen == gen {
+			return *e.Now, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (c *ReplayClock) After(d time.Duration) <-chan time.Time {
+	return c.newTimer(OpAfter, d, nil).ch
+}
+
+func (c *ReplayClock) AfterFunc(d time.Duration, f func()) Timer {
+	return c.newTimer(OpAfterFunc, d, f)
+}
+
+func (c *ReplayClock) Now() time.Time {
+	return *c.expect(Event{Op: OpNow}).Now
+}
+
+func (c *ReplayClock) Since(t time.Time) time.Duration {
+	return *c.expect(Event{Op: OpSince, Time: &t}).Result
+}
+
+func (c *ReplayClock) Until(t time.Time) time.Duration {
+	return *c.expect(Event{Op: OpUntil, Time: &t}).Result
+}
+
+func (c *ReplayClock) Sleep(d time.Duration) {
+	c.expect(Event{Op: OpSleep, Duration: d})
+}
+
+func (c *ReplayClock) Tick(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return c.newTicker(OpTick, d).ch
+}
+
+func (c *ReplayClock) Ticker(d time.Duration) Ticker {
+	return c.newTicker(OpTicker, d)
+}
+
+func (c *ReplayClock) Timer(d time.Duration) Timer {
+	return c.newTimer(OpTimer, d, nil)
+}
+
+func (c *ReplayClock) newTimer(op string, d time.Duration, f func()) *replayTimer {
+	t := &replayTimer{
+		c:   c,
+		ref: c.expect(Event{Op: op, Duration: d}).Seq,
+		f:   f,
+	}
+	if f == nil {
+		t.ch = make(chan time.Time, 1)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.arm()
+
+	return t
+}
+
+type replayTimer struct {
+	c   *ReplayClock
+	ref int
+	// nil for AfterFunc
+	ch chan time.Time
+	f  func()
+
+	mu  sync.Mutex
+	gen int
+}
+
+// arm delivers the value recorded for the current generation, if the timer fired at all.
+// Must only be used when holding the lock.
+func (t *replayTimer) arm() {
+	now, ok := t.c.fire(t.ref, t.gen)
+	if !ok {
+		return
+	}
+
+	if t.f != nil {
+		go t.f()
+		return
+	}
+	t.ch <- now
+}
+
+func (t *replayTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *replayTimer) Reset(d time.Duration) bool {
+	e := t.c.expect(Event{Op: OpReset, Ref: t.ref, Duration: d})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stop()
+	t.arm()
+
+	return *e.Active
+}
+
+func (t *replayTimer) Stop() bool {
+	e := t.c.expect(Event{Op: OpStop, Ref: t.ref})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stop()
+
+	return *e.Active
+}
+
+// stop must only be used when holding the lock
+func (t *replayTimer) stop() {
+	t.gen++
+	if t.ch != nil {
+		select {
+		case <-t.ch:
+		default:
+		}
+	}
+}
+
+func (c *ReplayClock) newTicker(op string, d time.Duration) *replayTicker {
+	t := &replayTicker{
+		c:      c,
+		ch:     make(chan time.Time),
+		stopCh: make(chan struct{}),
+	}
+	t.ref = c.expect(Event{Op: op, Duration: d}).Seq
+
+	go t.feed()
+
+	return t
+}
+
+type replayTicker struct {
+	c   *ReplayClock
+	ref int
+	ch  chan time.Time
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// feed delivers every recorded tick in order until stopped
+func (t *replayTicker) feed() {
+	t.c.mu.Lock()
+	fires := t.c.fires[t.ref]
+	t.c.mu.Unlock()
+
+	for _, e := range fires {
+		select {
+		case t.ch <- *e.Now:
+		case <-t.stopCh:
+			return
+		}
+	}
+}
+
+func (t *replayTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *replayTicker) Reset(d time.Duration) {
+	t.c.expect(Event{Op: OpReset, Ref: t.ref, Duration: d})
+}
+
+func (t *replayTicker) Stop() {
+	t.c.expect(Event{Op: OpStop, Ref: t.ref})
+	t.stopOnce.Do(func() { close(t.stopCh) })
+}
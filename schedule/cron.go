@@ -0,0 +1,331 @@
+// Package schedule runs jobs on a gotime.Clock according to cron expressions
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSpec is returned when a cron expression or descriptor can't be parsed
+var ErrInvalidSpec = errors.New("invalid cron spec")
+
+// Schedule describes when a job runs
+type Schedule interface {
+	// Next returns the first activation strictly after t, or the zero time if there is none
+	Next(t time.Time) time.Time
+}
+
+// Every is a Schedule that activates each time d elapses
+type Every time.Duration
+
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// Cron is a Schedule for a parsed cron expression
+type Cron struct {
+	second, minute, hour, dom, month, dow uint64
+
+	// Both day fields are restricted, so a day matching either is enough, as with cron
+	either bool
+	// Location the expression is evaluated in, or nil for the location of the time passed to Next
+	loc *time.Location
+
+	gap     GapPolicy
+	overlap OverlapPolicy
+}
+
+// Option configures how a cron expression is evaluated
+type Option func(*Cron)
+
+// How far ahead Next looks before deciding an expression never matches, such as 30 February
+const yearLimit = 5
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	seconds = bounds{min: 0, max: 59}
+	minutes = bounds{min: 0, max: 59}
+	hours   = bounds{min: 0, max: 23}
+	doms    = bounds{min: 1, max: 31}
+	months  = bounds{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted as Sunday too, and folded onto 0 once parsed
+	dows = bounds{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// Parse parses a standard 5 field cron expression (minute, hour, day of month, month, day of week), a 6 field one with
+// a leading seconds field, or one of the descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly
+// and "@every <duration>". Cron expressions are evaluated in the location of the time given to Next. Options only
+// apply to cron expressions, as @every counts absolute time.
+func Parse(spec string, opts ...Option) (Schedule, error) {
+	return ParseInLocation(spec, nil, opts...)
+}
+
+// ParseInLocation is Parse for cron expressions evaluated in loc, whatever the location of the time given to Next
+func ParseInLocation(spec string, loc *time.Location, opts ...Option) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := strings.CutPrefix(spec, "@every"); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", ErrInvalidSpec, spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("%w: %q: interval must be positive", ErrInvalidSpec, spec)
+		}
+		return Every(d), nil
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		expr, ok := descriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown descriptor %q", ErrInvalidSpec, spec)
+		}
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w: %q: expected 5 or 6 fields, got %d", ErrInvalidSpec, spec, len(fields))
+	}
+
+	c := &Cron{loc: loc}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for i, f := range []struct {
+		bits *uint64
+		b    bounds
+	}{
+		{&c.second, seconds},
+		{&c.minute, minutes},
+		{&c.hour, hours},
+		{&c.dom, doms},
+		{&c.month, months},
+		{&c.dow, dows},
+	} {
+		bits, err := parseField(fields[i], f.b)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", ErrInvalidSpec, spec, err)
+		}
+		*f.bits = bits
+	}
+
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	c.either = !isWildcard(fields[3]) && !isWildcard(fields[5])
+
+	return c, nil
+}
+
+func isWildcard(field string) bool {
+	return field == "*" || field == "?" || strings.HasPrefix(field, "*/")
+}
+
+// parseField returns a bit set of every value matched by a comma separated list of ranges
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, r := range strings.Split(field, ",") {
+		rbits, err := parseRange(r, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= rbits
+	}
+	return bits, nil
+}
+
+// parseRange parses *, ?, a single value, a-b, or any of those followed by /step
+func parseRange(r string, b bounds) (uint64, error) {
+	rng, stepStr, hasStep := strings.Cut(r, "/")
+
+	var lo, hi int
+	switch {
+	case rng == "*" || rng == "?":
+		lo, hi = b.min, b.max
+	default:
+		loStr, hiStr, isRange := strings.Cut(rng, "-")
+
+		var err error
+		if lo, err = parseValue(loStr, b); err != nil {
+			return 0, err
+		}
+		hi = lo
+		if isRange {
+			if hi, err = parseValue(hiStr, b); err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			// a/n means every n starting at a
+			hi = b.max
+		}
+	}
+	if lo > hi {
+		return 0, fmt.Errorf("range %q is backwards", r)
+	}
+
+	step := 1
+	if hasStep {
+		var err error
+		if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step in %q", r)
+		}
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << v
+	}
+	return bits, nil
+}
+
+func parseValue(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, b.min, b.max)
+	}
+	return v, nil
+}
+
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := c.loc
+	if loc == nil {
+		loc = t.Location()
+	}
+
+	// Search wall clock times, which are kept in UTC so that every day is 24 hours long, then map each match back onto
+	// loc. Around a change of offset, a later wall clock time can map to an earlier instant, so the search starts from
+	// t read in the smallest offset and carries on until no later wall clock time could beat the best instant found.
+	lo, hi := offsetRange(t, loc)
+	w := t.UTC().Add(lo).Truncate(time.Second).Add(time.Second)
+
+	var next time.Time
+	for {
+		var ok bool
+		if w, ok = c.nextWall(w); !ok {
+			break
+		}
+		if !next.IsZero() && !w.Add(-hi).Before(next) {
+			break
+		}
+
+		for _, at := range c.instants(w, loc) {
+			if at.After(t) && (next.IsZero() || at.Before(next)) {
+				next = at
+			}
+		}
+		w = w.Add(time.Second)
+	}
+	return next
+}
+
+// nextWall returns the first wall clock time at or after w that matches, with w in UTC
+func (c *Cron) nextWall(w time.Time) (time.Time, bool) {
+	limit := w.Year() + yearLimit
+
+	// Once a field is moved forward, every smaller field is reset to its lowest value
+	moved := false
+
+wrap:
+	if w.Year() > limit {
+		return time.Time{}, false
+	}
+
+	for c.month&(1<<uint(w.Month())) == 0 {
+		if !moved {
+			moved = true
+			w = time.Date(w.Year(), w.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+		w = w.AddDate(0, 1, 0)
+		if w.Month() == time.January {
+			goto wrap
+		}
+	}
+
+	for !c.dayMatches(w) {
+		if !moved {
+			moved = true
+			w = time.Date(w.Year(), w.Month(), w.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		w = w.AddDate(0, 0, 1)
+		if w.Day() == 1 {
+			goto wrap
+		}
+	}
+
+	for c.hour&(1<<uint(w.Hour())) == 0 {
+		if !moved {
+			moved = true
+			w = w.Truncate(time.Hour)
+		}
+		w = w.Add(time.Hour)
+		if w.Hour() == 0 {
+			goto wrap
+		}
+	}
+
+	for c.minute&(1<<uint(w.Minute())) == 0 {
+		if !moved {
+			moved = true
+			w = w.Truncate(time.Minute)
+		}
+		w = w.Add(time.Minute)
+		if w.Minute() == 0 {
+			goto wrap
+		}
+	}
+
+	for c.second&(1<<uint(w.Second())) == 0 {
+		if !moved {
+			moved = true
+		}
+		w = w.Add(time.Second)
+		if w.Second() == 0 {
+			goto wrap
+		}
+	}
+
+	return w, true
+}
+
+func (c *Cron) dayMatches(w time.Time) bool {
+	dom := c.dom&(1<<uint(w.Day())) != 0
+	dow := c.dow&(1<<uint(w.Weekday())) != 0
+	if c.either {
+		return dom || dow
+	}
+	return dom && dow
+}
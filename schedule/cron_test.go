@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseNext(t *testing.T) {
+	from := time.Date(2021, time.March, 15, 10, 30, 0, 0, time.UTC) // A Monday
+	tests := []struct {
+		spec string
+		exp  time.Time
+	}{
+		{spec: "* * * * *", exp: time.Date(2021, time.March, 15, 10, 31, 0, 0, time.UTC)},
+		{spec: "* * * * * *", exp: time.Date(2021, time.March, 15, 10, 30, 1, 0, time.UTC)},
+		{spec: "*/15 * * * *", exp: time.Date(2021, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 9-17 * * mon-fri", exp: time.Date(2021, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{spec: "0 9 * * SAT,sun", exp: time.Date(2021, time.March, 20, 9, 0, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", exp: time.Date(2021, time.March, 21, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1 jan *", exp: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 feb ?", exp: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{spec: "30 2/6 * * *", exp: time.Date(2021, time.March, 15, 14, 30, 0, 0, time.UTC)},
+		{spec: "0 0 1-5/2 * *", exp: time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted, so either matches
+		{spec: "0 0 1 * fri", exp: time.Date(2021, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 feb *", exp: time.Time{}},
+		{spec: "@hourly", exp: time.Date(2021, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{spec: "@daily", exp: time.Date(2021, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "@midnight", exp: time.Date(2021, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "@weekly", exp: time.Date(2021, time.March, 21, 0, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", exp: time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@yearly", exp: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@annually", exp: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@every 90m", exp: time.Date(2021, time.March, 15, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("got %s, want nil", err)
+			}
+
+			next := s.Next(from)
+			if !next.Equal(tt.exp) {
+				t.Errorf("got %s, want %s", next, tt.exp)
+			}
+		})
+	}
+}
+
+func TestParseInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s, err := ParseInLocation("0 9 * * *", loc)
+	if err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	from := time.Date(2021, time.March, 15, 6, 0, 0, 0, time.UTC)
+	exp := time.Date(2021, time.March, 15, 7, 0, 0, 0, time.UTC)
+	if next := s.Next(from); !next.Equal(exp) {
+		t.Errorf("got %s, want %s", next, exp)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@fortnightly",
+		"@every",
+		"@every -1s",
+		"@every soon",
+	}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			if _, err := Parse(tt); !errors.Is(err, ErrInvalidSpec) {
+				t.Errorf("got %v, want %s", err, ErrInvalidSpec)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+)
+
+// GapPolicy decides when to run an activation whose wall clock time is skipped as clocks go forward, such as 02:30 on
+// the day daylight saving time starts in most of Europe
+type GapPolicy int
+
+const (
+	// ShiftForward runs skipped activations at the instant the clocks go forward, the first wall clock time after the gap
+	ShiftForward GapPolicy = iota
+	// Skip doesn't run skipped activations at all
+	Skip
+)
+
+// OverlapPolicy decides how often to run an activation whose wall clock time happens twice as clocks go back, such as
+// 02:30 on the day daylight saving time ends in most of Europe
+type OverlapPolicy int
+
+const (
+	// RunOnce runs repeated activations the first time around only
+	RunOnce OverlapPolicy = iota
+	// RunTwice runs repeated activations both times around
+	RunTwice
+)
+
+// OnGap sets the policy for wall clock times skipped by a change of offset. The default is ShiftForward.
+func OnGap(p GapPolicy) Option {
+	return func(c *Cron) {
+		c.gap = p
+	}
+}
+
+// OnOverlap sets the policy for wall clock times repeated by a change of offset. The default is RunOnce.
+func OnOverlap(p OverlapPolicy) Option {
+	return func(c *Cron) {
+		c.overlap = p
+	}
+}
+
+// Changes of offset are assumed to be at least this far apart, which holds for every zone in tzdata
+const transitionSpacing = 24 * time.Hour
+
+// offsetRange returns the smallest and largest offsets of loc in use around t
+func offsetRange(t time.Time, loc *time.Location) (lo, hi time.Duration) {
+	for i, probe := range []time.Time{t.Add(-transitionSpacing), t, t.Add(transitionSpacing)} {
+		off := offset(probe, loc)
+		if i == 0 || off < lo {
+			lo = off
+		}
+		if i == 0 || off > hi {
+			hi = off
+		}
+	}
+	return lo, hi
+}
+
+func offset(t time.Time, loc *time.Location) time.Duration {
+	_, off := t.In(loc).Zone()
+	return time.Duration(off) * time.Second
+}
+
+// instants returns the instants, in order, that the wall clock time w (given in UTC) should run at in loc according to
+// the gap and overlap policies
+func (c *Cron) instants(w time.Time, loc *time.Location) []time.Time {
+	// Every instant that reads as w in loc, found by trying each offset in use around it
+	var valid []time.Time
+	lo, hi := offsetRange(w, loc)
+	for _, off := range []time.Duration{lo, hi} {
+		at := w.Add(-off).In(loc)
+		if !wall(at).Equal(w) || (len(valid) > 0 && valid[0].Equal(at)) {
+			continue
+		}
+		valid = append(valid, at)
+	}
+	sort.Slice(valid, func(i, j int) bool { return valid[i].Before(valid[j]) })
+
+	switch {
+	case len(valid) == 0 && c.gap == ShiftForward:
+		// Read in the earlier, smaller offset, w lands just after the clocks went forward
+		start, _ := w.Add(-lo).In(loc).ZoneBounds()
+		return []time.Time{start}
+	case len(valid) > 1 && c.overlap == RunOnce:
+		return valid[:1]
+	}
+	return valid
+}
+
+// wall returns the wall clock time of t, in UTC
+func wall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
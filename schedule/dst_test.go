@@ -0,0 +1,171 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/mgb/gotime"
+)
+
+func loadBerlin(t *testing.T) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+	return loc
+}
+
+// Clocks in Berlin go forward from 02:00 CET to 03:00 CEST on 28 March 2021, and back from 03:00 CEST to 02:00 CET on
+// 31 October 2021
+func TestCron_DST(t *testing.T) {
+	berlin := loadBerlin(t)
+	spring := time.Date(2021, time.March, 27, 12, 0, 0, 0, berlin)
+	autumn := time.Date(2021, time.October, 30, 12, 0, 0, 0, berlin)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		opts []Option
+		exp  []string
+	}{
+		{
+			name: "gap shifts forward",
+			spec: "30 2 * * *",
+			from: spring,
+			exp:  []string{"Mar 28 03:00 CEST", "Mar 29 02:30 CEST"},
+		},
+		{
+			name: "gap skips",
+			spec: "30 2 * * *",
+			from: spring,
+			opts: []Option{OnGap(Skip)},
+			exp:  []string{"Mar 29 02:30 CEST"},
+		},
+		{
+			name: "gap shifts several activations onto one",
+			spec: "*/20 2-3 * * *",
+			from: spring,
+			exp:  []string{"Mar 28 03:00 CEST", "Mar 28 03:20 CEST", "Mar 28 03:40 CEST", "Mar 29 02:00 CEST"},
+		},
+		{
+			name: "overlap runs once",
+			spec: "30 2 * * *",
+			from: autumn,
+			exp:  []string{"Oct 31 02:30 CEST", "Nov 1 02:30 CET"},
+		},
+		{
+			name: "overlap runs twice",
+			spec: "30 2 * * *",
+			from: autumn,
+			opts: []Option{OnOverlap(RunTwice)},
+			exp:  []string{"Oct 31 02:30 CEST", "Oct 31 02:30 CET", "Nov 1 02:30 CET"},
+		},
+		{
+			name: "overlap runs once in order",
+			spec: "*/30 2-3 * * *",
+			from: autumn,
+			exp: []string{
+				"Oct 31 02:00 CEST", "Oct 31 02:30 CEST", "Oct 31 03:00 CET", "Oct 31 03:30 CET",
+				"Nov 1 02:00 CET",
+			},
+		},
+		{
+			name: "overlap runs twice in order",
+			spec: "*/30 2-3 * * *",
+			from: autumn,
+			opts: []Option{OnOverlap(RunTwice)},
+			exp: []string{
+				"Oct 31 02:00 CEST", "Oct 31 02:30 CEST", "Oct 31 02:00 CET", "Oct 31 02:30 CET",
+				"Oct 31 03:00 CET", "Oct 31 03:30 CET", "Nov 1 02:00 CET",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseInLocation(tt.spec, berlin, tt.opts...)
+			if err != nil {
+				t.Fatalf("got %s, want nil", err)
+			}
+
+			var got []string
+			at := tt.from
+			for range tt.exp {
+				at = s.Next(at)
+				got = append(got, at.In(berlin).Format("Jan 2 15:04 MST"))
+			}
+			if !reflect.DeepEqual(got, tt.exp) {
+				t.Errorf("got %q, want %q", got, tt.exp)
+			}
+		})
+	}
+}
+
+func TestScheduler_DST(t *testing.T) {
+	berlin := loadBerlin(t)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		opts     []Option
+		exp      []string
+	}{
+		{
+			name: "spring shift forward",
+			from: time.Date(2021, time.March, 27, 12, 0, 0, 0, berlin),
+			to:   time.Date(2021, time.March, 29, 12, 0, 0, 0, berlin),
+			exp:  []string{"Mar 28 03:00 CEST", "Mar 29 02:30 CEST"},
+		},
+		{
+			name: "spring skip",
+			from: time.Date(2021, time.March, 27, 12, 0, 0, 0, berlin),
+			to:   time.Date(2021, time.March, 29, 12, 0, 0, 0, berlin),
+			opts: []Option{OnGap(Skip)},
+			exp:  []string{"Mar 29 02:30 CEST"},
+		},
+		{
+			name: "autumn once",
+			from: time.Date(2021, time.October, 30, 12, 0, 0, 0, berlin),
+			to:   time.Date(2021, time.November, 1, 12, 0, 0, 0, berlin),
+			exp:  []string{"Oct 31 02:30 CEST", "Nov 1 02:30 CET"},
+		},
+		{
+			name: "autumn twice",
+			from: time.Date(2021, time.October, 30, 12, 0, 0, 0, berlin),
+			to:   time.Date(2021, time.November, 1, 12, 0, 0, 0, berlin),
+			opts: []Option{OnOverlap(RunTwice)},
+			exp:  []string{"Oct 31 02:30 CEST", "Oct 31 02:30 CET", "Nov 1 02:30 CET"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := gotime.NewSettableClock()
+			c.SetNow(tt.from)
+			s := New(c)
+
+			sched, err := ParseInLocation("30 2 * * *", berlin, tt.opts...)
+			if err != nil {
+				t.Fatalf("got %s, want nil", err)
+			}
+
+			var runs []string
+			s.Schedule(sched, func(at time.Time) {
+				runs = append(runs, at.In(berlin).Format("Jan 2 15:04 MST"))
+			})
+
+			s.Start()
+			waitForTimers(t, c, 1)
+			c.SetNow(tt.to)
+			waitForTimers(t, c, 1)
+			s.Stop()
+
+			if !reflect.DeepEqual(runs, tt.exp) {
+				t.Errorf("got %q, want %q", runs, tt.exp)
+			}
+		})
+	}
+}
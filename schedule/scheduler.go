@@ -0,0 +1,250 @@
+package schedule
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+// EntryID identifies a job added to a Scheduler
+type EntryID int
+
+// Entry describes a job added to a Scheduler
+type Entry struct {
+	ID       EntryID
+	Schedule Schedule
+	// Next is when the job runs next, or the zero time if it never will
+	Next time.Time
+	// Prev is when the job last ran, or the zero time if it hasn't yet
+	Prev time.Time
+}
+
+type entry struct {
+	Entry
+	f func(time.Time)
+}
+
+// Scheduler runs jobs on a Clock, waiting on a single Timer for the earliest one. Jobs run one at a time in the
+// scheduler's own goroutine, in order of their scheduled time, then the order they were added. When the clock jumps
+// past several activations, they are all caught up in order, each being given the time it was scheduled for.
+type Scheduler struct {
+	c gotime.Clock
+
+	mu      sync.Mutex
+	entries []*entry
+	lastID  EntryID
+	running bool
+	// Wakes the running scheduler when entries change
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns a stopped Scheduler running jobs on c
+func New(c gotime.Clock) *Scheduler {
+	return &Scheduler{
+		c:    c,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// AddFunc parses spec with Parse and adds f to run on it. f is given the time it was scheduled for.
+func (s *Scheduler) AddFunc(spec string, f func(time.Time)) (EntryID, error) {
+	sched, err := Parse(spec)
+	if err != nil {
+		return 0, err
+	}
+	return s.Schedule(sched, f), nil
+}
+
+// Schedule adds f to run on sched, starting from the clock's current time. f is given the time it was scheduled for.
+func (s *Scheduler) Schedule(sched Schedule, f func(time.Time)) EntryID {
+	now := s.c.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	s.entries = append(s.entries, &entry{
+		Entry: Entry{
+			ID:       s.lastID,
+			Schedule: sched,
+			Next:     sched.Next(now),
+		},
+		f: f,
+	})
+	s.notify()
+
+	return s.lastID
+}
+
+// Remove stops the job from running again. A run already in progress is not interrupted.
+func (s *Scheduler) Remove(id EntryID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, e := range s.entries {
+		if e.ID == id {
+			// No need to wake the scheduler, it finds nothing due if its timer fires for this entry
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Entries describes every job, ordered by when they run next
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e.Entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return before(entries[i].Next, entries[j].Next)
+	})
+	return entries
+}
+
+// before orders times with the zero time, meaning never, last
+func before(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return !a.IsZero() && b.IsZero()
+	}
+	return a.Before(b)
+}
+
+// Start runs the scheduler in its own goroutine. Starting an already running scheduler does nothing.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go s.run(s.stop, s.done)
+}
+
+// Stop stops the scheduler, waiting for any job that is running to finish. Jobs missed while stopped are caught up
+// once started again.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	done := s.done
+	s.mu.Unlock()
+
+	<-done
+}
+
+// notify must only be used when holding the lock
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) run(stop, done chan struct{}) {
+	defer close(done)
+
+	var timer gotime.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		if !s.runDue(stop) {
+			return
+		}
+
+		var fire <-chan time.Time
+		if next, ok := s.next(); ok {
+			d := s.c.Until(next)
+			if timer == nil {
+				timer = s.c.Timer(d)
+			} else {
+				timer.Reset(d)
+			}
+			fire = timer.C()
+		} else if timer != nil {
+			timer.Stop()
+		}
+
+		select {
+		case <-fire:
+		case <-s.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// runDue runs every job that is due, earliest first, returning false if stopped part way
+func (s *Scheduler) runDue(stop chan struct{}) bool {
+	for {
+		select {
+		case <-stop:
+			return false
+		default:
+		}
+
+		now := s.c.Now()
+
+		s.mu.Lock()
+		e := s.earliest()
+		if e == nil || e.Next.After(now) {
+			s.mu.Unlock()
+			return true
+		}
+
+		// Catch up from the time it was due rather than now, so that no activation is missed
+		at := e.Next
+		e.Prev = at
+		e.Next = e.Schedule.Next(at)
+		f := e.f
+		s.mu.Unlock()
+
+		f(at)
+	}
+}
+
+// next returns when the earliest job is due
+func (s *Scheduler) next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := s.earliest()
+	if e == nil {
+		return time.Time{}, false
+	}
+	return e.Next, true
+}
+
+// earliest returns the job due first, breaking ties by the order they were added, or nil if none are ever due.
+// Must only be used when holding the lock.
+func (s *Scheduler) earliest() *entry {
+	var earliest *entry
+	for _, e := range s.entries {
+		if e.Next.IsZero() {
+			continue
+		}
+		if earliest == nil || e.Next.Before(earliest.Next) {
+			earliest = e
+		}
+	}
+	return earliest
+}
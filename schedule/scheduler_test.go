@@ -0,0 +1,183 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mgb/gotime"
+)
+
+// jobLog records which jobs ran and the time each was scheduled for
+type jobLog struct {
+	sync.Mutex
+	runs []string
+}
+
+func (l *jobLog) job(name string) func(time.Time) {
+	return func(t time.Time) {
+		l.Lock()
+		defer l.Unlock()
+
+		l.runs = append(l.runs, fmt.Sprintf("%s %s", t.Format("02 15:04"), name))
+	}
+}
+
+func (l *jobLog) get() []string {
+	l.Lock()
+	defer l.Unlock()
+
+	return append([]string(nil), l.runs...)
+}
+
+func waitForTimers(t *testing.T, c gotime.SettableClock, n int) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := c.BlockUntil(ctx, n); err != nil {
+		t.Fatalf("waiting for %d timers: %s", n, err)
+	}
+}
+
+func TestScheduler_Settable(t *testing.T) {
+	c := gotime.NewSettableClock()
+	s := New(c)
+
+	var log jobLog
+	for _, j := range []struct{ spec, name string }{
+		{spec: "@hourly", name: "hourly"},
+		{spec: "30 */6 * * *", name: "six-hourly"},
+		{spec: "@daily", name: "daily"},
+		{spec: "@every 10h", name: "every"},
+	} {
+		if _, err := s.AddFunc(j.spec, log.job(j.name)); err != nil {
+			t.Fatalf("got %s, want nil", err)
+		}
+	}
+
+	s.Start()
+	defer s.Stop()
+
+	waitForTimers(t, c, 1)
+	c.Add(24 * time.Hour)
+	// The scheduler arms its timer again once everything due has run
+	waitForTimers(t, c, 1)
+
+	var exp []string
+	for h := 0; h <= 24; h++ {
+		at := time.Date(1970, time.January, 1, h, 0, 0, 0, time.UTC).Format("02 15:04")
+		if h > 0 {
+			exp = append(exp, at+" hourly")
+		}
+		if h == 10 || h == 20 {
+			exp = append(exp, at+" every")
+		}
+		if h == 24 {
+			exp = append(exp, at+" daily")
+		}
+		if h%6 == 0 && h < 24 {
+			exp = append(exp, time.Date(1970, time.January, 1, h, 30, 0, 0, time.UTC).Format("02 15:04")+" six-hourly")
+		}
+	}
+
+	if runs := log.get(); !reflect.DeepEqual(runs, exp) {
+		t.Errorf("got %q, want %q", runs, exp)
+	}
+}
+
+func TestScheduler_Remove(t *testing.T) {
+	c := gotime.NewSettableClock()
+	s := New(c)
+
+	var log jobLog
+	hourly, _ := s.AddFunc("@hourly", log.job("hourly"))
+	if _, err := s.AddFunc("@every 90m", log.job("every")); err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	entries := s.Entries()
+	if len(entries) != 2 || entries[0].ID != hourly {
+		t.Fatalf("got %v, want hourly first", entries)
+	}
+
+	s.Start()
+	defer s.Stop()
+
+	waitForTimers(t, c, 1)
+	c.Add(time.Hour)
+	waitForTimers(t, c, 1)
+
+	s.Remove(hourly)
+	c.Add(2 * time.Hour)
+	waitForTimers(t, c, 1)
+
+	exp := []string{"01 01:00 hourly", "01 01:30 every", "01 03:00 every"}
+	if runs := log.get(); !reflect.DeepEqual(runs, exp) {
+		t.Errorf("got %q, want %q", runs, exp)
+	}
+}
+
+func TestScheduler_StopCatchesUp(t *testing.T) {
+	c := gotime.NewSettableClock()
+	s := New(c)
+
+	var log jobLog
+	if _, err := s.AddFunc("@hourly", log.job("hourly")); err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	s.Start()
+	waitForTimers(t, c, 1)
+	s.Stop()
+	waitForTimers(t, c, 0)
+
+	c.Add(2 * time.Hour)
+	if runs := log.get(); len(runs) != 0 {
+		t.Errorf("got %q while stopped, want nothing", runs)
+	}
+
+	s.Start()
+	defer s.Stop()
+	waitForTimers(t, c, 1)
+
+	exp := []string{"01 01:00 hourly", "01 02:00 hourly"}
+	if runs := log.get(); !reflect.DeepEqual(runs, exp) {
+		t.Errorf("got %q, want %q", runs, exp)
+	}
+}
+
+func TestScheduler_Warpable(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	// A day every half a second
+	start := time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)
+	c := gotime.NewTimeWarpableClock(gotime.WithStartTime(start), gotime.WithWarpSpeed(2*24*60*60))
+	s := New(c)
+
+	var log jobLog
+	if _, err := s.AddFunc("@hourly", log.job("hourly")); err != nil {
+		t.Fatalf("got %s, want nil", err)
+	}
+
+	s.Start()
+	time.Sleep(600 * time.Millisecond)
+	s.Stop()
+
+	runs := log.get()
+	if len(runs) < 24 {
+		t.Fatalf("got %d runs, want at least 24", len(runs))
+	}
+	for i, run := range runs {
+		exp := start.Add(time.Duration(i+1)*time.Hour).Format("02 15:04") + " hourly"
+		if run != exp {
+			t.Errorf("got %s, want %s", run, exp)
+		}
+	}
+}
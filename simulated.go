@@ -17,19 +17,37 @@ type simulation struct {
 	start time.Time
 	drift time.Duration
 	ratio float64
-
-	timers      queue.TimeQueue
-	timerCancel func()
+	// While paused, simulated time is frozen at start+drift
+	paused bool
+
+	timers queue.TimeQueue[*timerEntry]
+	// Whether to capture the stack of every timer armed, for Timers
+	stacks bool
+	// The seq given to the next timer armed or reset
+	seq uint64
+
+	// The dispatcher is the one goroutine that fires timers as the base clock reaches them, started whenever there is
+	// something for it to do. It is woken through wake whenever the queue or the flow of time changes.
+	dispatching  bool
+	dispatchDone chan struct{}
+	wake         chan struct{}
+	// Callbacks of timers fired by Add, SetNow and the like, waiting for the dispatcher to run them
+	pending []*timerEntry
+	closed  bool
+
+	// Signals changes to timers for BlockUntil
+	watch timerWatch
 
 	sync.RWMutex
 }
 
 func (s *simulation) String() string {
 	// Doesn't lock, to prevent recursive locking
-	return fmt.Sprintf("simulation{now: %s, start: %s, warp: %f, drift: %s, clock: %s, timers: %s}",
+	return fmt.Sprintf("simulation{now: %s, start: %s, warp: %f, paused: %t, drift: %s, clock: %s, timers: %s}",
 		s.lockedNow(),
 		s.start,
 		s.ratio,
+		s.paused,
 		s.drift,
 		s.c,
 		s.timers,
@@ -38,6 +56,9 @@ func (s *simulation) String() string {
 
 // lockedNow must only be used when holding the lock
 func (s *simulation) lockedNow() time.Time {
+	if s.paused {
+		return s.start.Add(s.drift)
+	}
 	return s.start.Add(s.toSimulatedDuration(s.c.Now().Sub(s.start)) + s.drift)
 }
 
@@ -45,20 +66,21 @@ func (s *simulation) toSimulatedDuration(d time.Duration) time.Duration {
 	return time.Duration(float64(d) * s.ratio)
 }
 
+// fromSimulatedDuration rounds up, so that a base timer never wakes up just before a simulated deadline
 func (s *simulation) fromSimulatedDuration(d time.Duration) time.Duration {
-	return time.Duration(float64(d) / s.ratio)
+	return time.Duration(math.Ceil(float64(d) / s.ratio))
 }
 
 func (s *simulation) Add(d time.Duration) time.Time {
 	s.Lock()
 	defer s.Unlock()
 
-	old := s.c.Now()
-	t := old.Add(d)
-	s.start = t
-	s.drift = old.Sub(s.start)
+	if s.closed {
+		return s.lockedNow()
+	}
 
-	s.triggerTimers(t)
+	old := s.lockedSetNow(s.lockedNow().Add(d))
+	s.triggerTimers()
 
 	return old
 }
@@ -67,20 +89,243 @@ func (s *simulation) SetNow(t time.Time) time.Time {
 	s.Lock()
 	defer s.Unlock()
 
-	old := s.c.Now()
-	s.start = t
-	s.drift = old.Sub(s.start)
+	if s.closed {
+		return s.lockedNow()
+	}
+
+	old := s.lockedSetNow(t)
+	s.triggerTimers()
+
+	return old
+}
+
+func (s *simulation) AddStrict(d time.Duration) (time.Time, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	old := s.lockedNow()
+	if s.closed {
+		return old, ErrClockClosed
+	}
+	if d < 0 {
+		return old, ErrTimeInPast
+	}
+	s.lockedSetNow(old.Add(d))
+	s.triggerTimers()
+
+	return old, nil
+}
+
+func (s *simulation) SetNowStrict(t time.Time) (time.Time, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	old := s.lockedNow()
+	if s.closed {
+		return old, ErrClockClosed
+	}
+	if t.Before(old) {
+		return old, ErrTimeInPast
+	}
+	s.lockedSetNow(t)
+	s.triggerTimers()
 
-	s.triggerTimers(t)
+	return old, nil
+}
 
+// lockedSetNow must only be used when holding the lock
+func (s *simulation) lockedSetNow(t time.Time) time.Time {
+	old := s.lockedNow()
+	s.start = s.c.Now()
+	s.drift = t.Sub(s.start)
 	return old
 }
 
-func (s *simulation) triggerTimers(t time.Time) {
+// triggerTimers fires every timer due by now, leaving their callbacks for the dispatcher to run.
+// Must be called during a write lock.
+func (s *simulation) triggerTimers() {
+	s.pending = append(s.pending, s.popTimers(s.lockedNow())...)
+
 	// Need to reset timers to the new time
-	if oldestT, ok := s.timers.Peek(); ok {
-		s.makeTimer(oldestT.Sub(t))
+	s.rearm()
+}
+
+// rearm starts the dispatcher if there is anything for it to do, or wakes it to take another look at the queue if it
+// is already running. Must be called during a write lock.
+func (s *simulation) rearm() {
+	if s.closed {
+		return
+	}
+
+	if s.dispatching {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	if _, ok := s.timers.Peek(); (!ok || s.paused) && len(s.pending) == 0 {
+		return
+	}
+	s.dispatching = true
+	s.dispatchDone = make(chan struct{})
+	go s.dispatch(s.dispatchDone)
+}
+
+// dispatch fires timers as the base clock reaches them, on a single timer of the base clock, and runs the callbacks of
+// every timer fired. It returns, closing done, once there are no timers left to wait for, or the clock is paused or
+// closed.
+func (s *simulation) dispatch(done chan struct{}) {
+	defer close(done)
+
+	var timer Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		s.Lock()
+		if s.closed {
+			s.dispatching = false
+			s.Unlock()
+			return
+		}
+
+		fired := append(s.pending, s.popTimers(s.lockedNow())...)
+		s.pending = nil
+
+		if len(fired) == 0 {
+			oldestT, ok := s.timers.Peek()
+			if !ok || s.paused {
+				s.dispatching = false
+				s.Unlock()
+				return
+			}
+
+			d := s.fromSimulatedDuration(oldestT.Sub(s.lockedNow()))
+			if timer == nil {
+				timer = s.c.Timer(d)
+			} else {
+				timer.Reset(d)
+			}
+		}
+		s.Unlock()
+
+		if len(fired) > 0 {
+			// Not here, so that callbacks can block or use the clock, and each in its own goroutine, started in the order
+			// the timers were armed, so that one can wait on another
+			for _, e := range fired {
+				go e.f()
+			}
+			continue
+		}
+
+		select {
+		case <-timer.C():
+		case <-s.wake:
+		}
+	}
+}
+
+// Close also stops the dispatcher, waiting for it to return
+func (s *simulation) Close() error {
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		return ErrClockClosed
+	}
+
+	// Freeze time where it is
+	s.lockedSetNow(s.lockedNow())
+	s.paused = true
+	s.closed = true
+
+	// Callbacks already due but not yet started by the dispatcher still run
+	due := s.pending
+	s.pending = nil
+	tickers := releaseTimers(s.timers, s.lockedNow())
+	s.watch.close()
+
+	done := s.dispatchDone
+	if s.dispatching {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	s.Unlock()
+
+	if done != nil {
+		<-done
+	}
+
+	for _, e := range due {
+		go e.f()
+	}
+	// Without the lock, as tickers use the clock to rearm
+	for _, e := range tickers {
+		e.f()
+	}
+
+	return nil
+}
+
+func (s *simulation) AdvanceToNext() (time.Time, bool) {
+	s.RLock()
+	next, ok := s.timers.Peek()
+	s.RUnlock()
+
+	if !ok {
+		return s.Now(), false
+	}
+	return s.advance(next)
+}
+
+func (s *simulation) RunUntil(t time.Time) {
+	for {
+		if _, ok := s.advance(t); !ok {
+			break
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return
+	}
+	if t.After(s.lockedNow()) {
+		s.lockedSetNow(t)
+	}
+	s.rearm()
+}
+
+// advance jumps simulated time to the earliest pending timer, as long as it is not after until, and fires every timer
+// due at it. Callbacks are run before returning so that any timers they arm are seen by the next call.
+func (s *simulation) advance(until time.Time) (time.Time, bool) {
+	s.Lock()
+
+	next, ok := s.timers.Peek()
+	if !ok || next.After(until) {
+		s.Unlock()
+		return time.Time{}, false
 	}
+
+	if next.After(s.lockedNow()) {
+		s.lockedSetNow(next)
+	}
+	fired := s.popTimers(next)
+	s.rearm()
+	s.Unlock()
+
+	for _, e := range fired {
+		e.f()
+	}
+
+	return next, true
 }
 
 func (s *simulation) SetWarpSpeed(ratio float64) error {
@@ -92,85 +337,195 @@ func (s *simulation) SetWarpSpeed(ratio float64) error {
 	s.Lock()
 	defer s.Unlock()
 
+	if s.closed {
+		return ErrClockClosed
+	}
+
 	now := s.lockedNow()
 	s.start = s.c.Now()
 	s.drift = now.Sub(s.start)
 	s.ratio = ratio
 
 	// Need to reset timers to the new warp speed
-	if oldestT, ok := s.timers.Peek(); ok {
-		s.makeTimer(oldestT.Sub(now))
-	}
+	s.rearm()
 
 	return nil
 }
 
-func (s *simulation) After(d time.Duration) <-chan time.Time {
+func (s *simulation) Pause() {
 	s.Lock()
 	defer s.Unlock()
 
-	oldestT, ok := s.timers.Peek()
+	if s.paused {
+		return
+	}
 
-	ch := make(chan time.Time, 1)
-	t := s.lockedNow().Add(d)
-	s.timers.Add(s.lockedNow().Add(d), ch)
+	s.lockedSetNow(s.lockedNow())
+	s.paused = true
 
-	if !ok || oldestT.After(t) {
-		// t is older than any other timer, create a new timer
-		s.makeTimer(d)
+	// Timers stay queued, but nothing will fire them until resumed
+	s.rearm()
+}
+
+func (s *simulation) Resume() {
+	s.Lock()
+	defer s.Unlock()
+
+	// Closing froze time for good
+	if !s.paused || s.closed {
+		return
 	}
+
+	now := s.lockedNow()
+	s.paused = false
+	s.lockedSetNow(now)
+
+	s.rearm()
+}
+
+func (s *simulation) After(d time.Duration) <-chan time.Time {
+	return s.after(d, KindAfter)
+}
+
+func (s *simulation) after(d time.Duration, kind TimerKind) <-chan time.Time {
+	s.Lock()
+	defer s.Unlock()
+
+	ch := make(chan time.Time, 1)
+	s.addTimer(d, ch, kind, nil)
 	return ch
 }
 
-// makeTimer must be called during a write lock
-func (s *simulation) makeTimer(d time.Duration) {
-	if s.timerCancel != nil {
-		s.timerCancel()
-	}
+func (s *simulation) AfterFunc(d time.Duration, f func()) Timer {
+	return newFakeTimer(d, nil, func(d time.Duration) timerHandle { return s.scheduleFunc(d, f) })
+}
 
-	ch := make(chan struct{}, 1)
-	ctx, cancel := context.WithCancel(context.Background())
-	s.timerCancel = cancel
+// scheduleFunc queues f to be called once d has elapsed in simulated time, in its own goroutine unless fired by
+// AdvanceToNext or RunUntil.
+func (s *simulation) scheduleFunc(d time.Duration, f func()) timerHandle {
+	s.Lock()
+	defer s.Unlock()
 
-	timer := s.c.Timer(s.fromSimulatedDuration(d))
-	go func() {
-		defer cancel()
+	return s.lockedScheduleFunc(s.lockedNow().Add(d), KindAfterFunc, f)
+}
 
-		select {
-		case <-timer.C():
-			ch <- struct{}{}
+// scheduleFuncAt is scheduleFunc for an absolute simulated time, used by tickers
+func (s *simulation) scheduleFuncAt(t time.Time, f func()) func() bool {
+	s.Lock()
+	defer s.Unlock()
 
-		case <-ctx.Done():
-			if !timer.Stop() {
-				<-timer.C()
-			}
-			close(ch)
-		}
-	}()
+	return s.lockedScheduleFunc(t, KindTicker, f).cancel
+}
 
-	go func() {
-		_, ok := <-ch
-		if !ok {
-			return
+// lockedScheduleFunc must only be used when holding the lock
+func (s *simulation) lockedScheduleFunc(t time.Time, kind TimerKind, f func()) timerHandle {
+	return s.handle(s.addTimerAt(t, nil, kind, f))
+}
+
+// addTimer must be called during a write lock
+func (s *simulation) addTimer(d time.Duration, ch chan<- time.Time, kind TimerKind, f func()) (remove func() bool, update func(t time.Time) bool) {
+	return s.addTimerAt(s.lockedNow().Add(d), ch, kind, f)
+}
+
+// addTimerAt queues ch to receive the simulated time once t is reached, or f to be called instead if set. The returned
+// functions remove it again or move it to fire at another time, reporting whether it was still pending.
+// Must be called during a write lock.
+func (s *simulation) addTimerAt(t time.Time, ch chan<- time.Time, kind TimerKind, f func()) (remove func() bool, update func(t time.Time) bool) {
+	if s.closed {
+		// Released straight away, the same as every channel pending when closed. Callbacks never come due, and tickers
+		// would only rearm and tick forever.
+		if ch != nil {
+			ch <- s.lockedNow()
 		}
+		return func() bool { return false }, func(time.Time) bool { return false }
+	}
 
-		s.Lock()
-		defer s.Unlock()
+	oldestT, ok := s.timers.Peek()
+
+	e := newTimerEntry(t, s.lockedNow(), kind, ch, f, s.stacks)
+	e.seq = s.seq
+	s.seq++
+	h := s.timers.Add(t, e)
+	s.watch.notify()
+
+	if !ok || oldestT.After(t) {
+		// t is older than any other timer, so the dispatcher needs to wait for it instead
+		s.rearm()
+	}
 
-		now := s.lockedNow()
-		for _, c := range s.timers.PopBeforeOrEqual(now) {
-			c <- now
+	// Like addTimerAt, the returned functions must be called during a write lock
+	remove = func() bool {
+		removed := s.timers.Remove(h)
+		if removed {
+			s.watch.notify()
+		}
+		return removed
+	}
+	update = func(t time.Time) bool {
+		if !s.timers.Update(h, t) {
+			return false
 		}
+		// Behind any timer already due at t, as the queue now orders it
+		e.deadline = t
+		e.seq = s.seq
+		s.seq++
+		s.watch.notify()
+		// The dispatcher may be waiting for a later timer than this one now is
+		s.rearm()
+		return true
+	}
+	return remove, update
+}
 
-		// Check to see if we need to make another timer for the next oldest remaining timer
-		oldestT, ok := s.timers.Peek()
-		if !ok {
-			s.timerCancel = nil
-			return
+// handle wraps the functions returned by addTimerAt into a timerHandle that takes the lock itself
+func (s *simulation) handle(remove func() bool, update func(t time.Time) bool) timerHandle {
+	return timerHandle{
+		cancel: func() bool {
+			s.Lock()
+			defer s.Unlock()
+
+			return remove()
+		},
+		reset: func(d time.Duration) bool {
+			s.Lock()
+			defer s.Unlock()
+
+			return update(s.lockedNow().Add(d))
+		},
+	}
+}
+
+// popTimers sends now to every timer due by now, returning the timers with callbacks that are due for the caller to
+// run. Must be called during a write lock.
+func (s *simulation) popTimers(now time.Time) []*timerEntry {
+	var fired []*timerEntry
+	popped := s.timers.PopBeforeOrEqual(now)
+	for _, e := range popped {
+		if e.f != nil {
+			fired = append(fired, e)
+			continue
 		}
+		e.ch <- now
+	}
+	if len(popped) > 0 {
+		s.watch.notify()
+	}
+	return fired
+}
 
-		s.makeTimer(oldestT.Sub(now))
-	}()
+func (s *simulation) BlockUntil(ctx context.Context, n int) error {
+	return blockUntil(ctx, n, &s.watch, s.timers.Len, s.Lock, s.Unlock)
+}
+
+func (s *simulation) WaitForTimer(ctx context.Context) error {
+	return s.BlockUntil(ctx, 1)
+}
+
+func (s *simulation) Timers() []TimerInfo {
+	s.RLock()
+	defer s.RUnlock()
+
+	return timerInfos(s.timers.Values())
 }
 
 func (s *simulation) Now() time.Time {
@@ -187,36 +542,42 @@ func (s *simulation) Since(t time.Time) time.Duration {
 	return s.lockedNow().Sub(t)
 }
 
+func (s *simulation) Until(t time.Time) time.Duration {
+	s.RLock()
+	defer s.RUnlock()
+
+	return t.Sub(s.lockedNow())
+}
+
 func (s *simulation) Sleep(d time.Duration) {
-	<-s.After(d)
+	<-s.after(d, KindSleep)
 }
 
-func (s *simulation) Timer(d time.Duration) Timer {
-	return s.newTimer(d)
+func (s *simulation) Tick(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return s.Ticker(d).C()
 }
 
-func (s *simulation) newTimer(d time.Duration) *fakeTimer {
-	s.Lock()
-	defer s.Unlock()
+func (s *simulation) Ticker(d time.Duration) Ticker {
+	return newFakeTicker(d, s.Now, s.scheduleFuncAt)
+}
 
+func (s *simulation) Timer(d time.Duration) Timer {
 	c := make(chan time.Time, 1)
-	closeCh := make(chan struct{})
-	done := make(chan struct{})
-
-	go func() {
-		defer close(done)
+	return newFakeTimer(d, c, func(d time.Duration) timerHandle { return s.scheduleChan(d, c) })
+}
 
-		select {
-		case now := <-s.After(d):
-			c <- now
-		case <-closeCh:
-		}
-	}()
+// scheduleChan queues ch to receive the simulated time once d has elapsed in simulated time.
+func (s *simulation) scheduleChan(d time.Duration, ch chan<- time.Time) timerHandle {
+	s.Lock()
+	defer s.Unlock()
 
-	return &fakeTimer{
-		c:        c,
-		close:    closeCh,
-		done:     done,
-		newTimer: s.newTimer,
+	if d <= 0 {
+		ch <- s.lockedNow()
+		return firedHandle
 	}
+
+	return s.handle(s.addTimer(d, ch, KindTimer, nil))
 }
@@ -1,52 +1,13 @@
 package gotime
 
 import (
+	"runtime"
 	"sync"
 	"testing"
+	"testing/quick"
 	"time"
 )
 
-func TestSimulatedTime_After_fake(t *testing.T) {
-	sim, f := newTimeWarpableClockWithFake(t)
-	select {
-	case <-f.timerAdded:
-		t.Error("got timer added, want nothing")
-	default:
-	}
-
-	ch := sim.After(time.Minute)
-	select {
-	case <-ch:
-		t.Error("got value, want nothing")
-	default:
-	}
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	var d time.Duration
-	go func() {
-		defer wg.Done()
-
-		t := time.Now()
-		<-ch
-		d = time.Since(t)
-	}()
-
-	select {
-	case <-f.timerAdded:
-	case <-time.After(100 * time.Millisecond):
-		t.Errorf("timer never added")
-	}
-	f.SetNow(f.Now().Add(time.Minute))
-
-	wg.Wait()
-
-	if d >= time.Second {
-		t.Errorf("got %s, want < 1s", d)
-	}
-}
-
 func TestSimulatedTime_Now_fake(t *testing.T) {
 	sim, f := newTimeWarpableClockWithFake(t)
 
@@ -125,7 +86,7 @@ func TestSimulatedTime_Since_fake(t *testing.T) {
 	}
 }
 
-func TestSimulatedTime_Sleep_fake(t *testing.T) {
+func TestSimulatedTime_Sleep_Warped_fake(t *testing.T) {
 	sim, f := newTimeWarpableClockWithFake(t)
 
 	var wg sync.WaitGroup
@@ -136,13 +97,21 @@ func TestSimulatedTime_Sleep_fake(t *testing.T) {
 		defer wg.Done()
 
 		t := time.Now()
-		sim.Sleep(time.Minute)
+		sim.Sleep(3 * time.Minute)
 		d = time.Since(t)
 	}()
 
-	<-f.timerAdded
+	waitForTimers(t, sim, 1)
+
+	sim.SetWarpSpeed(60)
+	f.SetNow(f.Now().Add(time.Second))
+
+	sim.SetWarpSpeed(1)
 	f.SetNow(f.Now().Add(time.Minute))
 
+	sim.SetWarpSpeed(60)
+	f.SetNow(f.Now().Add(time.Second))
+
 	wg.Wait()
 
 	if d >= time.Second {
@@ -150,74 +119,237 @@ func TestSimulatedTime_Sleep_fake(t *testing.T) {
 	}
 }
 
-func TestSimulatedTime_Sleep_Warped_fake(t *testing.T) {
+func TestSimulatedTime_AfterFunc_fake(t *testing.T) {
 	sim, f := newTimeWarpableClockWithFake(t)
 
-	var wg sync.WaitGroup
-	wg.Add(1)
+	called := make(chan struct{})
+	sim.AfterFunc(time.Minute, func() { close(called) })
 
-	var d time.Duration
-	go func() {
-		defer wg.Done()
+	waitForTimers(t, sim, 1)
+	sim.SetWarpSpeed(60)
+	f.SetNow(f.Now().Add(time.Second))
 
-		t := time.Now()
-		sim.Sleep(3 * time.Minute)
-		d = time.Since(t)
-	}()
+	select {
+	case <-called:
+	case <-time.After(100 * time.Millisecond):
+		t.Error("callback took too long to run")
+	}
+}
 
-	<-f.timerAdded
+func TestSimulatedTime_AfterFunc_Stop_fake(t *testing.T) {
+	sim, f := newTimeWarpableClockWithFake(t)
+
+	called := make(chan struct{})
+	timer := sim.AfterFunc(time.Minute, func() { close(called) })
+
+	if !timer.Stop() {
+		t.Error("got false stopping a pending timer, want true")
+	}
+
+	waitForTimers(t, sim, 0)
+	f.SetNow(f.Now().Add(time.Minute))
+
+	select {
+	case <-called:
+		t.Error("got call, want nothing")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestSimulatedTime_Ticker_fake(t *testing.T) {
+	sim, f := newTimeWarpableClockWithFake(t)
+
+	ticker := sim.Ticker(time.Minute)
+	defer ticker.Stop()
+
+	expectTick := func() {
+		t.Helper()
+
+		select {
+		case <-ticker.C():
+		case <-time.After(100 * time.Millisecond):
+			t.Error("ticker took too long to trigger")
+		}
+	}
 
 	sim.SetWarpSpeed(60)
 	f.SetNow(f.Now().Add(time.Second))
+	expectTick()
+
+	sim.SetWarpSpeed(120)
+	f.SetNow(f.Now().Add(time.Second / 2))
+	expectTick()
 
 	sim.SetWarpSpeed(1)
 	f.SetNow(f.Now().Add(time.Minute))
+	expectTick()
+}
 
+func TestSimulatedTime_SetNow_fake(t *testing.T) {
+	sim, f := newTimeWarpableClockWithFake(t)
 	sim.SetWarpSpeed(60)
+
+	want := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	prev := sim.Now()
+	if old := sim.SetNow(want); old != prev {
+		t.Errorf("got %s, want %s", old, prev)
+	}
+	if now := sim.Now(); now != want {
+		t.Errorf("got %s, want %s", now, want)
+	}
+
 	f.SetNow(f.Now().Add(time.Second))
+	want = want.Add(time.Minute)
+	if now := sim.Now(); now != want {
+		t.Errorf("got %s, want %s", now, want)
+	}
 
-	wg.Wait()
+	sim.Add(time.Hour)
+	want = want.Add(time.Hour)
+	if now := sim.Now(); now != want {
+		t.Errorf("got %s, want %s", now, want)
+	}
+}
 
-	if d >= time.Second {
-		t.Errorf("got %s, want < 1s", d)
+func TestSimulatedTime_RunUntil_fake(t *testing.T) {
+	sim, _ := newTimeWarpableClockWithFake(t)
+	start := sim.Now()
+
+	ch := sim.After(time.Hour)
+	var calledAt time.Time
+	sim.AfterFunc(time.Minute, func() { calledAt = sim.Now() })
+
+	next, ok := sim.AdvanceToNext()
+	if !ok {
+		t.Fatal("got false, want true")
+	}
+	if want := start.Add(time.Minute); next != want || calledAt != want {
+		t.Errorf("got %s and callback at %s, want %s", next, calledAt, want)
+	}
+
+	end := start.Add(24 * time.Hour)
+	sim.RunUntil(end)
+	if now := sim.Now(); now != end {
+		t.Errorf("got %s, want %s", now, end)
 	}
+
+	select {
+	case got := <-ch:
+		if want := start.Add(time.Hour); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	default:
+		t.Error("got nothing, want value")
+	}
+}
+
+func TestSimulatedTime_BlockUntil_fake(t *testing.T) {
+	sim, f := newTimeWarpableClockWithFake(t)
+	sim.SetWarpSpeed(60)
+
+	n := 3
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sim.Sleep(time.Minute)
+		}()
+	}
+
+	waitForTimers(t, sim, n)
+	f.SetNow(f.Now().Add(time.Second))
+	wg.Wait()
+
+	waitForTimers(t, sim, 0)
 }
 
-func TestSimulatedTime_Timer_fake(t *testing.T) {
+func TestSimulatedTime_Pause_fake(t *testing.T) {
 	sim, f := newTimeWarpableClockWithFake(t)
+	sim.SetWarpSpeed(60)
+
+	ch := sim.After(time.Minute)
 
-	timer := sim.Timer(time.Minute)
+	sim.Pause()
+	paused := sim.Now()
 
+	f.SetNow(f.Now().Add(time.Hour))
+	if now := sim.Now(); now != paused {
+		t.Errorf("got %s, want %s", now, paused)
+	}
 	select {
-	case <-timer.C():
-		t.Error("got value, want nothing")
-	default:
+	case <-ch:
+		t.Error("got value while paused, want nothing")
+	case <-time.After(10 * time.Millisecond):
+	}
+	waitForTimers(t, sim, 1)
+
+	sim.Resume()
+	if now := sim.Now(); now != paused {
+		t.Errorf("got %s, want %s", now, paused)
+	}
+
+	f.SetNow(f.Now().Add(time.Second))
+	if want := paused.Add(time.Minute); sim.Now() != want {
+		t.Errorf("got %s, want %s", sim.Now(), want)
 	}
 	select {
-	case <-f.timerAdded:
-		t.Error("got timer added, want nothing")
-	default:
+	case <-ch:
+	case <-time.After(100 * time.Millisecond):
+		t.Error("timer took too long to trigger")
 	}
+}
 
-	var wg sync.WaitGroup
-	wg.Add(1)
+func TestNewTimeWarpableClock_Options_fake(t *testing.T) {
+	f := NewSettableClock()
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
 
-	var d time.Duration
-	go func() {
-		defer wg.Done()
+	sim := NewTimeWarpableClock(
+		WithBaseClock(f),
+		WithStartTime(start),
+		WithWarpSpeed(60),
+	)
 
-		t := time.Now()
-		<-timer.C()
-		d = time.Since(t)
+	if now := sim.Now(); now != start {
+		t.Errorf("got %s, want %s", now, start)
+	}
+
+	f.Add(time.Second)
+	if want := start.Add(time.Minute); sim.Now() != want {
+		t.Errorf("got %s, want %s", sim.Now(), want)
+	}
+}
+
+func TestWithWarpSpeed_Invalid(t *testing.T) {
+	defer func() {
+		if r := recover(); r != ErrNegativeRatio {
+			t.Errorf("got %v, want %v", r, ErrNegativeRatio)
+		}
 	}()
 
-	<-f.timerAdded
-	f.SetNow(f.Now().Add(time.Minute))
+	WithWarpSpeed(0)
+}
 
-	wg.Wait()
+func TestSimulatedTime_SetNowStrict_fake(t *testing.T) {
+	sim, _ := newTimeWarpableClockWithFake(t)
+	start := sim.Now()
 
-	if d >= time.Second {
-		t.Errorf("got %s, want < 1s", d)
+	if _, err := sim.SetNowStrict(start.Add(-time.Second)); err != ErrTimeInPast {
+		t.Errorf("got %v, want %v", err, ErrTimeInPast)
+	}
+	if _, err := sim.AddStrict(-time.Second); err != ErrTimeInPast {
+		t.Errorf("got %v, want %v", err, ErrTimeInPast)
+	}
+	if now := sim.Now(); now != start {
+		t.Errorf("got %s, want %s", now, start)
+	}
+
+	if _, err := sim.AddStrict(time.Minute); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	if want := start.Add(time.Minute); sim.Now() != want {
+		t.Errorf("got %s, want %s", sim.Now(), want)
 	}
 }
 
@@ -227,9 +359,152 @@ func newTimeWarpableClockWithFake(t *testing.T) (TimeWarpableClock, *faketime) {
 	if !ok {
 		t.Fatalf("got %T, want *faketime", s)
 	}
-	c := NewTimeWarpableClock()
-	sim := c.(*simulation)
-	sim.c = f
-	sim.start = f.Now()
-	return c, f
+	return NewTimeWarpableClock(WithBaseClock(f)), f
+}
+
+func TestSimulatedTime_ArmingOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		advance func(sim TimeWarpableClock, f *faketime, d time.Duration)
+		ordered bool
+	}{
+		{name: "RunUntil", advance: func(sim TimeWarpableClock, f *faketime, d time.Duration) { sim.RunUntil(sim.Now().Add(d)) }, ordered: true},
+		{name: "Add", advance: func(sim TimeWarpableClock, f *faketime, d time.Duration) { sim.Add(d) }},
+		// Fired by the dispatcher
+		{name: "base clock", advance: func(sim TimeWarpableClock, f *faketime, d time.Duration) { f.Add(d) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newClock := func() (SettableClock, func(d time.Duration)) {
+				sim, f := newTimeWarpableClockWithFake(t)
+				return sim, func(d time.Duration) { tt.advance(sim, f, d) }
+			}
+			if err := quick.Check(armedInOrder(newClock, tt.ordered), nil); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSimulatedTime_Dispatcher_fake(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	// Plenty of abandoned timers across a few clocks still only need one goroutine per clock
+	clocks := make([]TimeWarpableClock, 10)
+	for i := range clocks {
+		sim, f := newTimeWarpableClockWithFake(t)
+		for j := 1; j <= 100; j++ {
+			d := time.Duration(j) * time.Minute
+			sim.After(d)
+			sim.Timer(d)
+			sim.AfterFunc(d, func() {})
+			sim.Ticker(d)
+		}
+		// The dispatcher waits on the base clock for the earliest of them
+		waitForTimers(t, f, 1)
+		clocks[i] = sim
+	}
+	waitForGoroutines(t, before+len(clocks))
+
+	// None of them left behind once closed
+	for _, sim := range clocks {
+		if err := sim.Close(); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	}
+	waitForGoroutines(t, before)
+}
+
+func TestSimulatedTime_Dispatcher_Idle_fake(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	sim, f := newTimeWarpableClockWithFake(t)
+	defer sim.Close()
+
+	ch := sim.After(time.Minute)
+	waitForTimers(t, f, 1)
+
+	// Nothing to wait for while paused
+	sim.Pause()
+	waitForTimers(t, f, 0)
+	waitForGoroutines(t, before)
+	sim.Resume()
+	waitForTimers(t, f, 1)
+
+	f.Add(time.Minute)
+	select {
+	case <-ch:
+	case <-time.After(100 * time.Millisecond):
+		t.Error("timer took too long to trigger")
+	}
+
+	// Nor once every timer has fired
+	waitForGoroutines(t, before)
+}
+
+func TestSimulatedTime_Close_fake(t *testing.T) {
+	sim, f := newTimeWarpableClockWithFake(t)
+	sim.SetWarpSpeed(60)
+	sim.Pause()
+	sim.Resume()
+	ch := sim.After(time.Minute)
+	due := make(chan struct{})
+	sim.AfterFunc(time.Second, func() { close(due) })
+	called := make(chan struct{})
+	sim.AfterFunc(time.Minute, func() { close(called) })
+	// Fired, though the dispatcher may not have started the callback yet
+	sim.Add(time.Second)
+
+	closed := sim.Now()
+	if err := sim.Close(); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	// Callbacks already due still run, unlike those that never came due
+	select {
+	case <-due:
+	case <-time.After(100 * time.Millisecond):
+		t.Error("callback took too long to run")
+	}
+	select {
+	case <-called:
+		t.Error("got callback run, want nothing")
+	case <-time.After(10 * time.Millisecond):
+	}
+	select {
+	case got := <-ch:
+		if got != closed {
+			t.Errorf("got %s, want %s", got, closed)
+		}
+	default:
+		t.Error("got nothing, want value")
+	}
+
+	// Neither the base clock nor resuming moves time on
+	f.Add(time.Hour)
+	sim.Resume()
+	f.Add(time.Hour)
+	if now := sim.Now(); now != closed {
+		t.Errorf("got %s, want %s", now, closed)
+	}
+	if err := sim.SetWarpSpeed(2); err != ErrClockClosed {
+		t.Errorf("got %v, want %v", err, ErrClockClosed)
+	}
+}
+
+// waitForGoroutines waits for no more than n goroutines to be running, as goroutines take a moment to exit. Those left
+// behind by other tests may exit meanwhile, so there can be fewer.
+func waitForGoroutines(t *testing.T, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := runtime.NumGoroutine()
+		if got <= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d goroutines, want %d", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
 }
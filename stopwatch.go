@@ -0,0 +1,119 @@
+package gotime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Stopwatch measures elapsed time on a Clock, keeping a history of laps. Under a TimeWarpableClock it measures
+// simulated time. Safe for concurrent use.
+type Stopwatch struct {
+	c Clock
+
+	mu      sync.Mutex
+	running bool
+	// When the current run was started
+	started time.Time
+	// Elapsed time of every run before the current one
+	elapsed time.Duration
+	// Elapsed time when the current lap started
+	lapStart time.Duration
+	laps     []time.Duration
+}
+
+// NewStopwatch returns a stopped Stopwatch measuring time on c
+func NewStopwatch(c Clock) *Stopwatch {
+	return &Stopwatch{
+		c: c,
+	}
+}
+
+func (s *Stopwatch) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fmt.Sprintf("stopwatch{elapsed: %s, running: %t, laps: %v}", s.lockedElapsed(), s.running, s.laps)
+}
+
+// Start starts or resumes the stopwatch. Does nothing if it is already running.
+func (s *Stopwatch) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+	s.started = s.c.Now()
+}
+
+// Stop pauses the stopwatch, returning the elapsed time. Does nothing if it is not running.
+func (s *Stopwatch) Stop() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		s.elapsed += s.c.Since(s.started)
+		s.running = false
+	}
+	return s.elapsed
+}
+
+// Reset clears the elapsed time and laps, leaving the stopwatch running if it was
+func (s *Stopwatch) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.elapsed = 0
+	s.lapStart = 0
+	s.laps = nil
+	if s.running {
+		s.started = s.c.Now()
+	}
+}
+
+// Lap records and returns the time elapsed since the previous lap, or since the start for the first lap
+func (s *Stopwatch) Lap() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := s.lockedElapsed()
+	lap := total - s.lapStart
+	s.lapStart = total
+	s.laps = append(s.laps, lap)
+
+	return lap
+}
+
+// Laps returns every lap recorded since the last Reset, oldest first
+func (s *Stopwatch) Laps() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]time.Duration(nil), s.laps...)
+}
+
+// Elapsed returns the total time the stopwatch has been running since the last Reset
+func (s *Stopwatch) Elapsed() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lockedElapsed()
+}
+
+// Running reports whether the stopwatch is running
+func (s *Stopwatch) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.running
+}
+
+// lockedElapsed must only be used when holding the lock
+func (s *Stopwatch) lockedElapsed() time.Duration {
+	if !s.running {
+		return s.elapsed
+	}
+	return s.elapsed + s.c.Since(s.started)
+}
@@ -0,0 +1,99 @@
+package gotime
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStopwatch(t *testing.T) {
+	c := NewSettableClock()
+	s := NewStopwatch(c)
+
+	c.Add(time.Hour)
+	if d := s.Elapsed(); d != 0 {
+		t.Errorf("got %s before starting, want 0", d)
+	}
+
+	s.Start()
+	c.Add(time.Second)
+	if lap := s.Lap(); lap != time.Second {
+		t.Errorf("got lap %s, want %s", lap, time.Second)
+	}
+	c.Add(2 * time.Second)
+
+	if d := s.Stop(); d != 3*time.Second {
+		t.Errorf("got %s, want %s", d, 3*time.Second)
+	}
+	if s.Running() {
+		t.Error("got running, want stopped")
+	}
+
+	// Time doesn't count while stopped
+	c.Add(time.Hour)
+	s.Start()
+	c.Add(3 * time.Second)
+	if lap := s.Lap(); lap != 5*time.Second {
+		t.Errorf("got lap %s, want %s", lap, 5*time.Second)
+	}
+
+	if d := s.Elapsed(); d != 6*time.Second {
+		t.Errorf("got %s, want %s", d, 6*time.Second)
+	}
+	if want := []time.Duration{time.Second, 5 * time.Second}; !reflect.DeepEqual(s.Laps(), want) {
+		t.Errorf("got laps %v, want %v", s.Laps(), want)
+	}
+
+	s.Reset()
+	if d := s.Elapsed(); d != 0 {
+		t.Errorf("got %s after reset, want 0", d)
+	}
+	if laps := s.Laps(); len(laps) != 0 {
+		t.Errorf("got laps %v after reset, want none", laps)
+	}
+	c.Add(time.Second)
+	if d := s.Elapsed(); d != time.Second {
+		t.Errorf("got %s, want %s", d, time.Second)
+	}
+}
+
+func TestStopwatch_Warped(t *testing.T) {
+	sim, f := newTimeWarpableClockWithFake(t)
+	sim.SetWarpSpeed(60)
+
+	s := NewStopwatch(sim)
+	s.Start()
+	f.Add(time.Second)
+
+	if d := s.Elapsed(); d != time.Minute {
+		t.Errorf("got %s, want %s", d, time.Minute)
+	}
+}
+
+func TestStopwatch_Concurrent(t *testing.T) {
+	c := NewSettableClock()
+	s := NewStopwatch(c)
+	s.Start()
+
+	n := 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			c.Add(time.Second)
+			s.Lap()
+			s.Elapsed()
+		}()
+	}
+	wg.Wait()
+
+	if laps := s.Laps(); len(laps) != n {
+		t.Errorf("got %d laps, want %d", len(laps), n)
+	}
+	if d := s.Stop(); d != time.Duration(n)*time.Second {
+		t.Errorf("got %s, want %s", d, time.Duration(n)*time.Second)
+	}
+}